@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/atsushi-ishibashi/aws-state-report/svc"
+	"github.com/atsushi-ishibashi/aws-state-report/util"
+	"github.com/urfave/cli"
+)
+
+func NewConfigCommand() cli.Command {
+	return cli.Command{
+		Name:  "config",
+		Usage: "validate the credentials and print the identity and region the commands use.",
+		Action: func(c *cli.Context) error {
+			if err := util.ConfigAWS(c); err != nil {
+				return util.ErrorRed(err.Error())
+			}
+			ctx, cancel := withTimeout(c.GlobalDuration("timeout"))
+			defer cancel()
+			mng, err := svc.NewManager(ctx)
+			if err != nil {
+				return util.ErrorRed(err.Error())
+			}
+			identity, err := mng.WhoAmI()
+			if err != nil {
+				return util.ErrorRed(fmt.Sprintf("failed to get the caller identity: %s", err))
+			}
+			if c.GlobalBool("dry-run") {
+				return nil
+			}
+			profile := c.GlobalString("awsconf")
+			if profile == "" {
+				profile = "- (default credential chain)"
+			}
+			role := c.GlobalString("assume-role-arn")
+			if role == "" {
+				role = "-"
+			}
+			fmt.Printf("Profile:     %s\n", profile)
+			fmt.Printf("Assume Role: %s\n", role)
+			fmt.Printf("Region:      %s\n", identity.Region)
+			fmt.Printf("Account:     %s\n", identity.Account)
+			fmt.Printf("Arn:         %s\n", identity.Arn)
+			fmt.Printf("User ID:     %s\n", identity.UserID)
+			util.PrintlnGreen("credentials resolved")
+			return nil
+		},
+	}
+}
@@ -0,0 +1,335 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/atsushi-ishibashi/aws-state-report/svc"
+	"github.com/atsushi-ishibashi/aws-state-report/util"
+	"github.com/jung-kurt/gofpdf"
+	"github.com/urfave/cli"
+)
+
+func NewDiffCommand() cli.Command {
+	return cli.Command{
+		Name:  "diff",
+		Usage: "compare vpcs, subnets and routes of two json reports of the network command.",
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "old",
+				Usage: "json report to compare from",
+			},
+			cli.StringFlag{
+				Name:  "new",
+				Usage: "json report to compare to",
+			},
+			cli.StringFlag{
+				Name:  "format",
+				Usage: "output format. text prints to stdout, pdf writes diff.pdf",
+				Value: "text",
+			},
+			cli.BoolFlag{
+				Name:  "allow-partial",
+				Usage: "compare reports with partial true. the resources of the failed fetches are reported as added or removed",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			if c.String("old") == "" || c.String("new") == "" {
+				return util.ErrorRed("--old and --new are required")
+			}
+			format := c.String("format")
+			if format != "text" && format != "pdf" {
+				return util.ErrorRed(fmt.Sprintf("unknown format: %s. text or pdf", format))
+			}
+			if format == "pdf" {
+				if err := validateFontFile(c.GlobalString("font")); err != nil {
+					return util.ErrorRed(err.Error())
+				}
+			}
+			oldReport, err := loadReport(c.String("old"))
+			if err != nil {
+				return util.ErrorRed(err.Error())
+			}
+			newReport, err := loadReport(c.String("new"))
+			if err != nil {
+				return util.ErrorRed(err.Error())
+			}
+			for _, r := range []struct {
+				path   string
+				report *svc.Report
+			}{{c.String("old"), oldReport}, {c.String("new"), newReport}} {
+				if !r.report.Partial {
+					continue
+				}
+				if !c.Bool("allow-partial") {
+					return util.ErrorRed(fmt.Sprintf("%s is a partial report with %d errors. pass --allow-partial to compare it anyway", r.path, len(r.report.Errors)))
+				}
+				util.PrintlnYellow(fmt.Sprintf("%s is a partial report. the resources of its failed fetches are reported as added or removed", r.path))
+			}
+			d := &Diff{
+				Old:      oldReport,
+				New:      newReport,
+				fontFile: c.GlobalString("font"),
+				Errs:     make([]error, 0),
+			}
+			d.compare()
+			if format == "pdf" {
+				d.convertPdf()
+			} else {
+				d.printText()
+			}
+			if err := d.flattenErrs(); err != nil {
+				return util.ErrorRed(err.Error())
+			}
+			return nil
+		},
+	}
+}
+
+type Diff struct {
+	Old      *svc.Report
+	New      *svc.Report
+	Items    []*DiffItem
+	fontFile string
+	Errs     []error
+}
+
+//loadReport reads a json report of the network command
+func loadReport(path string) (*svc.Report, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	r := &svc.Report{}
+	if err := json.Unmarshal(b, r); err != nil {
+		return nil, fmt.Errorf("%s is not a json report: %s", path, err)
+	}
+	if r.Version != svc.ReportVersion {
+		return nil, fmt.Errorf("%s is a report of version %q. only version %s is supported", path, r.Version, svc.ReportVersion)
+	}
+	return r, nil
+}
+
+func (d *Diff) compare() {
+	d.Items = make([]*DiffItem, 0)
+	oldVpcs := make(map[string]*Vpc)
+	for _, v := range d.Old.Vpcs {
+		oldVpcs[v.ID] = v
+	}
+	newVpcs := make(map[string]*Vpc)
+	for _, v := range d.New.Vpcs {
+		newVpcs[v.ID] = v
+	}
+	for _, v := range d.New.Vpcs {
+		ov, ok := oldVpcs[v.ID]
+		if !ok {
+			d.add(diffAdded, "vpc", v.ID, v.TagName, v.CidrBlock)
+			d.compareVpc(&Vpc{}, v)
+			continue
+		}
+		if details := diffFields([][3]string{{"name", ov.TagName, v.TagName}, {"cidr", ov.CidrBlock, v.CidrBlock}}); details != "" {
+			d.add(diffChanged, "vpc", v.ID, v.TagName, details)
+		}
+		d.compareVpc(ov, v)
+	}
+	for _, v := range d.Old.Vpcs {
+		if _, ok := newVpcs[v.ID]; !ok {
+			d.add(diffRemoved, "vpc", v.ID, v.TagName, v.CidrBlock)
+			d.compareVpc(v, &Vpc{})
+		}
+	}
+}
+
+//compareVpc compares the subnets and the routes of the route tables of the vpcs
+func (d *Diff) compareVpc(ov, nv *Vpc) {
+	oldSns := make(map[string]*Subnet)
+	for _, sn := range ov.Subnets {
+		oldSns[sn.ID] = sn
+	}
+	newSns := make(map[string]bool)
+	for _, sn := range nv.Subnets {
+		newSns[sn.ID] = true
+		osn, ok := oldSns[sn.ID]
+		if !ok {
+			d.add(diffAdded, "subnet", sn.ID, sn.TagName, fmt.Sprintf("%s %s", sn.CidrBlock, sn.AvailabilityZone))
+			continue
+		}
+		details := diffFields([][3]string{
+			{"name", osn.TagName, sn.TagName},
+			{"cidr", osn.CidrBlock, sn.CidrBlock},
+			{"az", osn.AvailabilityZone, sn.AvailabilityZone},
+		})
+		if details != "" {
+			d.add(diffChanged, "subnet", sn.ID, sn.TagName, details)
+		}
+	}
+	for _, sn := range ov.Subnets {
+		if !newSns[sn.ID] {
+			d.add(diffRemoved, "subnet", sn.ID, sn.TagName, fmt.Sprintf("%s %s", sn.CidrBlock, sn.AvailabilityZone))
+		}
+	}
+	oldRoutes := make(map[string]*Route)
+	for _, rt := range ov.RouteTables {
+		for _, r := range rt.Routes {
+			oldRoutes[rt.ID+" "+routeKey(r)] = r
+		}
+	}
+	newRoutes := make(map[string]bool)
+	for _, rt := range nv.RouteTables {
+		for _, r := range rt.Routes {
+			key := rt.ID + " " + routeKey(r)
+			newRoutes[key] = true
+			or, ok := oldRoutes[key]
+			if !ok {
+				d.add(diffAdded, "route", key, rt.TagName, r.Target())
+			} else if or.Router != r.Router {
+				d.add(diffChanged, "route", key, rt.TagName, fmt.Sprintf("target: %s -> %s", or.Target(), r.Target()))
+			}
+		}
+	}
+	for _, rt := range ov.RouteTables {
+		for _, r := range rt.Routes {
+			key := rt.ID + " " + routeKey(r)
+			if !newRoutes[key] {
+				d.add(diffRemoved, "route", key, rt.TagName, r.Target())
+			}
+		}
+	}
+}
+
+//routeKey is the destination of the route by id, not by the resolved name of the prefix list
+func routeKey(r *Route) string {
+	switch {
+	case r.DestinationIpv6CidrBlock != "":
+		return r.DestinationIpv6CidrBlock
+	case r.DestinationPrefixListID != "":
+		return r.DestinationPrefixListID
+	default:
+		return r.DestinationCidrBlock
+	}
+}
+
+//diffFields returns "field: old -> new" of the fields changed, each of which is {field, old, new}
+func diffFields(fields [][3]string) string {
+	changes := make([]string, 0)
+	for _, f := range fields {
+		if f[1] != f[2] {
+			changes = append(changes, fmt.Sprintf("%s: %s -> %s", f[0], f[1], f[2]))
+		}
+	}
+	return strings.Join(changes, ", ")
+}
+
+func (d *Diff) add(change, typ, id, name, detail string) {
+	d.Items = append(d.Items, &DiffItem{Change: change, Type: typ, ID: id, Name: name, Detail: detail})
+}
+
+var diffTypeOrder = map[string]int{"vpc": 0, "subnet": 1, "route": 2}
+
+//sections groups the items by change, each sorted by vpcs, subnets and routes
+func (d *Diff) sections() map[string][]*DiffItem {
+	grouped := make(map[string][]*DiffItem)
+	for _, item := range d.Items {
+		grouped[item.Change] = append(grouped[item.Change], item)
+	}
+	for _, items := range grouped {
+		sort.SliceStable(items, func(i, j int) bool {
+			if items[i].Type != items[j].Type {
+				return diffTypeOrder[items[i].Type] < diffTypeOrder[items[j].Type]
+			}
+			return items[i].ID < items[j].ID
+		})
+	}
+	return grouped
+}
+
+//partialErrors are the errors of the partial reports prefixed by old or new
+func (d *Diff) partialErrors() []string {
+	errs := make([]string, 0)
+	for _, r := range []struct {
+		label  string
+		report *svc.Report
+	}{{"old", d.Old}, {"new", d.New}} {
+		for _, e := range r.report.Errors {
+			errs = append(errs, fmt.Sprintf("%s: %s", r.label, e))
+		}
+	}
+	return errs
+}
+
+func (d *Diff) printText() {
+	fmt.Printf("%s -> %s\n", d.Old.GeneratedAt, d.New.GeneratedAt)
+	if errs := d.partialErrors(); len(errs) > 0 {
+		fmt.Println("errors of the reports:")
+		for _, e := range errs {
+			fmt.Printf("  %s\n", e)
+		}
+	}
+	if len(d.Items) == 0 {
+		fmt.Println("no changes")
+		return
+	}
+	grouped := d.sections()
+	for _, change := range []string{diffAdded, diffRemoved, diffChanged} {
+		items := grouped[change]
+		if len(items) == 0 {
+			continue
+		}
+		fmt.Printf("\n%s (%d)\n", change, len(items))
+		for _, item := range items {
+			fmt.Printf("  %-6s %s %s  %s\n", item.Type, item.ID, item.Name, item.Detail)
+		}
+	}
+}
+
+func (d *Diff) convertPdf() {
+	widths := []float64{20, 60, 40, 70}
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	font := pdfFont(pdf, d.fontFile)
+	setPdfFooter(pdf, font)
+	pdf.AddPage()
+	pdf.SetFont(font, "", 8)
+	pdf.CellFormat(0, 10, fmt.Sprintf("%s -> %s", d.Old.GeneratedAt, d.New.GeneratedAt), "", 1, "L", false, 0, "")
+	if errs := d.partialErrors(); len(errs) > 0 {
+		pdf.SetTextColor(220, 0, 0)
+		pdf.CellFormat(0, 6, "Errors of the reports:", "", 1, "L", false, 0, "")
+		for _, e := range errs {
+			pdf.CellFormat(0, 6, fitPdfText(pdf, e, pdfContentWidth(pdf)), "", 1, "L", false, 0, "")
+		}
+		pdf.SetTextColor(0, 0, 0)
+		pdf.Ln(4)
+	}
+	grouped := d.sections()
+	for _, change := range []string{diffAdded, diffRemoved, diffChanged} {
+		items := grouped[change]
+		header := []string{"Type", "ID", "Name", "Detail"}
+		printHeader := func() {
+			writePdfTableHeader(pdf, widths, header, 8)
+		}
+		pdf.CellFormat(0, 10, fmt.Sprintf("%s (%d)", strings.Title(change), len(items)), "1", 1, "C", false, 0, "")
+		printHeader()
+		for _, item := range items {
+			writePdfMultiLineRow(pdf, widths, 6, []string{item.Type, item.ID, item.Name, item.Detail}, printHeader)
+		}
+		if len(items) == 0 {
+			pdf.CellFormat(0, 8, "None", "1", 1, "C", false, 0, "")
+		}
+		pdf.Ln(6)
+	}
+	if err := pdf.OutputFileAndClose(outputPath("diff.pdf")); err != nil {
+		d.stackError(err)
+	}
+}
+
+func (d *Diff) stackError(err error) *Diff {
+	util.LogError(err)
+	d.Errs = append(d.Errs, err)
+	return d
+}
+
+func (d *Diff) flattenErrs() error {
+	return svc.NewAggregateError(d.Errs)
+}
@@ -0,0 +1,16 @@
+package cmd
+
+//Changes of DiffItem
+const (
+	diffAdded   = "added"
+	diffRemoved = "removed"
+	diffChanged = "changed"
+)
+
+type DiffItem struct {
+	Change string
+	Type   string
+	ID     string
+	Name   string
+	Detail string
+}
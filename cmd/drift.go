@@ -0,0 +1,208 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/atsushi-ishibashi/aws-state-report/svc"
+	"github.com/atsushi-ishibashi/aws-state-report/util"
+	"github.com/jung-kurt/gofpdf"
+	"github.com/urfave/cli"
+)
+
+//driftTypes maps terraform resource types to the kind of network resource they manage
+var driftTypes = map[string]string{
+	"aws_vpc":                 "aws_vpc",
+	"aws_default_vpc":         "aws_vpc",
+	"aws_subnet":              "aws_subnet",
+	"aws_default_subnet":      "aws_subnet",
+	"aws_route_table":         "aws_route_table",
+	"aws_default_route_table": "aws_route_table",
+}
+
+func NewDriftCommand() cli.Command {
+	return cli.Command{
+		Name:  "drift",
+		Usage: "compare vpcs, subnets and route tables in aws with a terraform state file.",
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "state",
+				Usage: "terraform state file path or s3://bucket/key[?versionId=...]",
+			},
+			cli.BoolFlag{
+				Name:  "fail-on-drift",
+				Usage: "exit with non-zero status after writing the report when unmanaged or drifted resources are found",
+			},
+			cli.IntFlag{
+				Name:  "max-drift",
+				Usage: "number of unmanaged and drifted resources tolerated by --fail-on-drift",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			if c.String("state") == "" {
+				return util.ErrorRed("--state is required")
+			}
+			if c.IsSet("max-drift") && !c.Bool("fail-on-drift") {
+				return util.ErrorRed("--max-drift requires --fail-on-drift")
+			}
+			if c.Int("max-drift") < 0 {
+				return util.ErrorRed("--max-drift must be 0 or more")
+			}
+			if err := validateFontFile(c.GlobalString("font")); err != nil {
+				return util.ErrorRed(err.Error())
+			}
+			if err := util.ConfigAWS(c); err != nil {
+				return util.ErrorRed(err.Error())
+			}
+			ctx, cancel := withTimeout(c.GlobalDuration("timeout"))
+			defer cancel()
+			mng, err := svc.NewManager(ctx)
+			if err != nil {
+				return util.ErrorRed(err.Error())
+			}
+			resources, err := loadTfState(mng, c.String("state"))
+			if err != nil {
+				return util.ErrorRed(err.Error())
+			}
+			d := &Drift{
+				StateResources: resources,
+				manager:        mng,
+				fontFile:       c.GlobalString("font"),
+				Errs:           make([]error, 0),
+			}
+			if err := d.recursiveConstruct(); err != nil {
+				return util.ErrorRed(err.Error())
+			}
+			if c.GlobalBool("dry-run") {
+				return nil
+			}
+			d.compare()
+			d.convertPdf()
+			if err := d.flattenErrs(); err != nil {
+				return util.ErrorRed(err.Error())
+			}
+			if len(d.Unmanaged) == 0 && len(d.Drifted) == 0 {
+				util.PrintlnGreen("no drift")
+			} else {
+				util.PrintlnYellow(fmt.Sprintf("unmanaged: %d, drifted: %d", len(d.Unmanaged), len(d.Drifted)))
+			}
+			if n := len(d.Unmanaged) + len(d.Drifted); c.Bool("fail-on-drift") && n > c.Int("max-drift") {
+				return util.ErrorRed(fmt.Sprintf("%d resources drifted, more than --max-drift %d", n, c.Int("max-drift")))
+			}
+			return nil
+		},
+	}
+}
+
+type Drift struct {
+	StateResources []*StateResource
+	Vpcs           []*Vpc
+	//Unmanaged are resources in aws but not in the state
+	Unmanaged []*DriftItem
+	//Drifted are resources in the state but not in aws
+	Drifted  []*DriftItem
+	manager  *svc.Manager
+	fontFile string
+	Errs     []error
+}
+
+func (d *Drift) recursiveConstruct() error {
+	vpcs, err := svc.BuildNetworkModel(d.manager, svc.NetworkOptions{})
+	d.Vpcs = vpcs
+	if err != nil {
+		d.stackError(err)
+	}
+	return d.flattenErrs()
+}
+
+func (d *Drift) compare() {
+	live := make(map[string]*DriftItem)
+	for _, v := range d.Vpcs {
+		live[v.ID] = &DriftItem{Type: "aws_vpc", ID: v.ID, Name: v.TagName}
+		for _, sn := range v.Subnets {
+			live[sn.ID] = &DriftItem{Type: "aws_subnet", ID: sn.ID, Name: sn.TagName}
+		}
+		for _, rt := range v.RouteTables {
+			live[rt.ID] = &DriftItem{Type: "aws_route_table", ID: rt.ID, Name: rt.TagName}
+		}
+	}
+	managed := make(map[string]bool)
+	d.Drifted = make([]*DriftItem, 0)
+	for _, r := range d.StateResources {
+		kind, ok := driftTypes[r.Type]
+		if !ok {
+			continue
+		}
+		managed[r.ID] = true
+		if item, ok := live[r.ID]; !ok || item.Type != kind {
+			d.Drifted = append(d.Drifted, &DriftItem{Type: r.Type, ID: r.ID, Address: r.Address})
+		}
+	}
+	d.Unmanaged = make([]*DriftItem, 0)
+	for id, item := range live {
+		if !managed[id] {
+			d.Unmanaged = append(d.Unmanaged, item)
+		}
+	}
+	sortDriftItems(d.Unmanaged)
+	sortDriftItems(d.Drifted)
+}
+
+func (d *Drift) convertPdf() {
+	widths := []float64{50, 60, 80}
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	font := pdfFont(pdf, d.fontFile)
+	setPdfFooter(pdf, font)
+	pdf.AddPage()
+	pdf.SetFont(font, "", 8)
+	pdf.CellFormat(0, 10, fmt.Sprintf("Region: %s", d.manager.Region), "", 1, "L", false, 0, "")
+	sections := []struct {
+		title  string
+		header string
+		items  []*DriftItem
+	}{
+		{"Unmanaged: in AWS but not in state", "Name", d.Unmanaged},
+		{"Drift: in state but not in AWS", "Address", d.Drifted},
+	}
+	for _, s := range sections {
+		header := []string{"Type", "ID", s.header}
+		printHeader := func() {
+			writePdfTableHeader(pdf, widths, header, 8)
+		}
+		pdf.CellFormat(0, 10, fmt.Sprintf("%s (%d)", s.title, len(s.items)), "1", 1, "C", false, 0, "")
+		printHeader()
+		for _, item := range s.items {
+			name := item.Name
+			if s.header == "Address" {
+				name = item.Address
+			}
+			writePdfMultiLineRow(pdf, widths, 6, []string{item.Type, item.ID, name}, printHeader)
+		}
+		if len(s.items) == 0 {
+			pdf.CellFormat(0, 8, "None", "1", 1, "C", false, 0, "")
+		}
+		pdf.Ln(6)
+	}
+	if err := pdf.OutputFileAndClose(outputPath("drift.pdf")); err != nil {
+		d.stackError(err)
+	}
+}
+
+func (d *Drift) stackError(err error) *Drift {
+	util.LogError(err)
+	d.Errs = append(d.Errs, err)
+	return d
+}
+
+func (d *Drift) flattenErrs() error {
+	return svc.NewAggregateError(d.Errs)
+}
+
+func sortDriftItems(items []*DriftItem) {
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Type != items[j].Type {
+			return items[i].Type < items[j].Type
+		}
+		return items[i].ID < items[j].ID
+	})
+}
@@ -0,0 +1,8 @@
+package cmd
+
+type DriftItem struct {
+	Type    string
+	ID      string
+	Name    string
+	Address string
+}
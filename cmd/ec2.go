@@ -0,0 +1,248 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/atsushi-ishibashi/aws-state-report/svc"
+	"github.com/atsushi-ishibashi/aws-state-report/util"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/jung-kurt/gofpdf"
+	"github.com/urfave/cli"
+)
+
+func NewEC2Command() cli.Command {
+	return cli.Command{
+		Name:  "ec2",
+		Usage: "export ec2 instances grouped by vpc in pdf file.",
+		Flags: []cli.Flag{
+			cli.BoolFlag{
+				Name:  "include-terminated",
+				Usage: "include terminated instances.",
+			},
+			cli.StringFlag{
+				Name:  "state",
+				Usage: "export only instances in the comma separated states, e.g. running,stopped. " + strings.Join(ec2.InstanceStateName_Values(), ", "),
+			},
+			cli.DurationFlag{
+				Name:  "since",
+				Usage: "export only instances launched within the duration, e.g. 24h",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			if err := validateFontFile(c.GlobalString("font")); err != nil {
+				return util.ErrorRed(err.Error())
+			}
+			states, err := parseInstanceStates(c.String("state"), c.Bool("include-terminated"))
+			if err != nil {
+				return util.ErrorRed(err.Error())
+			}
+			if err := util.ConfigAWS(c); err != nil {
+				return util.ErrorRed(err.Error())
+			}
+			ctx, cancel := withTimeout(c.GlobalDuration("timeout"))
+			defer cancel()
+			mng, err := svc.NewManager(ctx)
+			if err != nil {
+				return util.ErrorRed(err.Error())
+			}
+			e := &EC2{
+				manager:  mng,
+				fontFile: c.GlobalString("font"),
+				states:   states,
+				since:    c.Duration("since"),
+				Errs:     make([]error, 0),
+			}
+			if err := e.recursiveConstruct(); err != nil {
+				return util.ErrorRed(err.Error())
+			}
+			if c.GlobalBool("dry-run") {
+				return nil
+			}
+			e.convertPdf()
+			if err := e.flattenErrs(); err != nil {
+				return util.ErrorRed(err.Error())
+			}
+			return nil
+		},
+	}
+}
+
+type EC2 struct {
+	Instances []*Instance
+	manager   *svc.Manager
+	fontFile  string
+	states    []string
+	since     time.Duration
+	Errs      []error
+}
+
+func (e *EC2) recursiveConstruct() error {
+	e.constructInstances()
+	return e.flattenErrs()
+}
+
+func (e *EC2) constructInstances() *EC2 {
+	filters := make([]*ec2.Filter, 0)
+	if len(e.states) > 0 {
+		filters = append(filters, &ec2.Filter{
+			Name:   aws.String("instance-state-name"),
+			Values: aws.StringSlice(e.states),
+		})
+	}
+	result, err := e.manager.FetchInstances(filters...)
+	if err != nil {
+		return e.stackError(err)
+	}
+	instances := make([]*Instance, 0)
+	launchedAfter := time.Now().Add(-e.since)
+	for _, v := range parseDescribeInstancesOutputToInstances(result) {
+		//instances without launch time are excluded
+		if e.since > 0 && v.LaunchTime.Before(launchedAfter) {
+			continue
+		}
+		instances = append(instances, v)
+	}
+	e.Instances = instances
+	return e
+}
+
+func (e *EC2) convertPdf() {
+	vpcIDs := make([]string, 0)
+	grouped := make(map[string][]*Instance)
+	for _, v := range e.Instances {
+		if _, ok := grouped[v.VpcID]; !ok {
+			vpcIDs = append(vpcIDs, v.VpcID)
+		}
+		grouped[v.VpcID] = append(grouped[v.VpcID], v)
+	}
+
+	header := []string{"ID", "Name", "Type", "State", "Private IP", "Public IP", "Subnet"}
+	widths := []float64{32, 34, 22, 20, 26, 26, 30}
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	font := pdfFont(pdf, e.fontFile)
+	pdf.AddPage()
+	pdf.SetFont(font, "", 8)
+	pdf.CellFormat(0, 10, fmt.Sprintf("Region: %s", e.manager.Region), "", 1, "L", false, 0, "")
+	for i, vpcID := range vpcIDs {
+		if i > 0 {
+			pdf.AddPage()
+		}
+		title := vpcID
+		if title == "" {
+			title = "EC2-Classic"
+		}
+		pdf.CellFormat(0, 10, fmt.Sprintf("%s  (%d instances)", title, len(grouped[vpcID])), "1", 0, "C", false, 0, "")
+		pdf.Ln(-1)
+		for j, h := range header {
+			pdf.CellFormat(widths[j], 10, h, "1", 0, "C", false, 0, "")
+		}
+		pdf.Ln(-1)
+		for _, ins := range grouped[vpcID] {
+			row := []string{ins.ID, ins.TagName, ins.InstanceType, ins.State, ins.PrivateIP, ins.PublicIP, ins.SubnetID}
+			for j, col := range row {
+				pdf.CellFormat(widths[j], 10, fitPdfText(pdf, col, widths[j]), "1", 0, "C", false, 0, "")
+			}
+			pdf.Ln(-1)
+		}
+	}
+	if len(vpcIDs) == 0 {
+		pdf.CellFormat(0, 10, "No Instances", "1", 0, "C", false, 0, "")
+	}
+	if err := pdf.OutputFileAndClose(outputPath("ec2.pdf")); err != nil {
+		e.stackError(err)
+	}
+}
+
+func (e *EC2) stackError(err error) *EC2 {
+	util.LogError(err)
+	e.Errs = append(e.Errs, err)
+	return e
+}
+
+func (e *EC2) flattenErrs() error {
+	return svc.NewAggregateError(e.Errs)
+}
+
+//parseInstanceStates validates the comma separated states.
+//All states but terminated are returned when none is given, and nil, meaning all, with includeTerminated.
+func parseInstanceStates(s string, includeTerminated bool) ([]string, error) {
+	known := ec2.InstanceStateName_Values()
+	if s == "" {
+		if includeTerminated {
+			return nil, nil
+		}
+		states := make([]string, 0, len(known))
+		for _, v := range known {
+			if v != ec2.InstanceStateNameTerminated {
+				states = append(states, v)
+			}
+		}
+		return states, nil
+	}
+	if includeTerminated {
+		return nil, fmt.Errorf("--include-terminated cannot be used with --state. add terminated to --state instead")
+	}
+	states := make([]string, 0)
+	for _, v := range strings.Split(s, ",") {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		valid := false
+		for _, k := range known {
+			if v == k {
+				valid = true
+			}
+		}
+		if !valid {
+			return nil, fmt.Errorf("unknown instance state %s. one of %s", v, strings.Join(known, ", "))
+		}
+		states = append(states, v)
+	}
+	return states, nil
+}
+
+func parseDescribeInstancesOutputToInstances(output *ec2.DescribeInstancesOutput) []*Instance {
+	instances := make([]*Instance, 0)
+	for _, r := range output.Reservations {
+		for _, v := range r.Instances {
+			if v.InstanceId == nil {
+				continue
+			}
+			ins := &Instance{
+				ID:           *v.InstanceId,
+				TagName:      extractTagName(v.Tags),
+				InstanceType: stringOrDash(v.InstanceType),
+			}
+			if v.State != nil && v.State.Name != nil {
+				ins.State = *v.State.Name
+			}
+			if v.Placement != nil && v.Placement.AvailabilityZone != nil {
+				ins.AvailabilityZone = *v.Placement.AvailabilityZone
+			}
+			if v.PrivateIpAddress != nil {
+				ins.PrivateIP = *v.PrivateIpAddress
+			}
+			if v.PublicIpAddress != nil {
+				ins.PublicIP = *v.PublicIpAddress
+			}
+			if v.KeyName != nil {
+				ins.KeyName = *v.KeyName
+			}
+			if v.SubnetId != nil {
+				ins.SubnetID = *v.SubnetId
+			}
+			if v.VpcId != nil {
+				ins.VpcID = *v.VpcId
+			}
+			if v.LaunchTime != nil {
+				ins.LaunchTime = *v.LaunchTime
+			}
+			instances = append(instances, ins)
+		}
+	}
+	return instances
+}
@@ -0,0 +1,220 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/atsushi-ishibashi/aws-state-report/svc"
+	"github.com/atsushi-ishibashi/aws-state-report/util"
+	"github.com/jung-kurt/gofpdf"
+	"github.com/urfave/cli"
+)
+
+func NewECSCommand() cli.Command {
+	return cli.Command{
+		Name:  "ecs",
+		Usage: "export ecs clusters and services with the vpc subnets of awsvpc mode in pdf file.",
+		Action: func(c *cli.Context) error {
+			if err := validateFontFile(c.GlobalString("font")); err != nil {
+				return util.ErrorRed(err.Error())
+			}
+			if err := util.ConfigAWS(c); err != nil {
+				return util.ErrorRed(err.Error())
+			}
+			ctx, cancel := withTimeout(c.GlobalDuration("timeout"))
+			defer cancel()
+			mng, err := svc.NewManager(ctx)
+			if err != nil {
+				return util.ErrorRed(err.Error())
+			}
+			e := &ECS{
+				manager:  mng,
+				fontFile: c.GlobalString("font"),
+				Errs:     make([]error, 0),
+			}
+			//the clusters fetched successfully are written even if others failed
+			e.recursiveConstruct()
+			if c.GlobalBool("dry-run") {
+				return nil
+			}
+			e.convertPdf()
+			if err := e.flattenErrs(); err != nil {
+				return util.ErrorRed(err.Error())
+			}
+			return nil
+		},
+	}
+}
+
+type ECS struct {
+	Clusters []*Cluster
+	//subnets are those of the network model by id, with the vpcs they are in
+	subnets  map[string]*ecsSubnet
+	manager  *svc.Manager
+	fontFile string
+	Errs     []error
+}
+
+type ecsSubnet struct {
+	*Subnet
+	vpc *Vpc
+}
+
+func (e *ECS) recursiveConstruct() error {
+	e.constructClusters().
+		constructSubnets()
+	return e.flattenErrs()
+}
+
+func (e *ECS) constructClusters() *ECS {
+	clusters, err := e.manager.FetchClusters()
+	if err != nil {
+		return e.stackError(err)
+	}
+	for _, cl := range clusters {
+		services, err := e.manager.FetchServices(cl.Arn)
+		if err != nil {
+			e.stackError(fmt.Errorf("%s: %w", cl.Name, err))
+			continue
+		}
+		cl.Services = services
+	}
+	e.Clusters = clusters
+	return e
+}
+
+//constructSubnets builds the network model to correlate the subnets of awsvpc mode services
+func (e *ECS) constructSubnets() *ECS {
+	e.subnets = make(map[string]*ecsSubnet)
+	awsvpc := false
+	for _, cl := range e.Clusters {
+		for _, s := range cl.Services {
+			awsvpc = awsvpc || s.IsAwsvpc()
+		}
+	}
+	if !awsvpc {
+		return e
+	}
+	vpcs, err := svc.BuildNetworkModel(e.manager, svc.NetworkOptions{
+		SkipNetworkAcls:  true,
+		SkipVpcEndpoints: true,
+		SkipFlowLogs:     true,
+		SkipDhcpOptions:  true,
+	})
+	var agg *svc.AggregateError
+	if errors.As(err, &agg) {
+		//each error has been logged by BuildNetworkModel. overlapping subnets are not fetch failures
+		//and are left to the network command.
+		for _, err := range agg.Errors() {
+			var overlap *svc.SubnetOverlapError
+			if !errors.As(err, &overlap) {
+				e.Errs = append(e.Errs, err)
+			}
+		}
+	} else if err != nil {
+		e.stackError(err)
+	}
+	for _, v := range vpcs {
+		for _, sn := range v.Subnets {
+			e.subnets[sn.ID] = &ecsSubnet{Subnet: sn, vpc: v}
+		}
+	}
+	return e
+}
+
+//subnetLabels returns a line per subnet of the service and the vpcs they are in
+func (e *ECS) subnetLabels(s *ECSService) (subnets, vpcs []string) {
+	if !s.IsAwsvpc() {
+		return []string{"-"}, []string{"-"}
+	}
+	seen := make(map[string]bool)
+	for _, id := range s.Subnets {
+		sn, ok := e.subnets[id]
+		if !ok {
+			subnets = append(subnets, fmt.Sprintf("%s (not found)", id))
+			continue
+		}
+		access := "private"
+		if sn.IsPublic() {
+			access = "public"
+		}
+		subnets = append(subnets, fmt.Sprintf("%s %s %s %s", nameWithID(sn.TagName, sn.ID), sn.CidrBlock, subnetZone(sn.Subnet), access))
+		if !seen[sn.vpc.ID] {
+			seen[sn.vpc.ID] = true
+			vpcs = append(vpcs, nameWithID(sn.vpc.TagName, sn.vpc.ID))
+		}
+	}
+	if len(vpcs) == 0 {
+		vpcs = []string{"-"}
+	}
+	return subnets, vpcs
+}
+
+//convertPdf writes a page per cluster. Services running fewer tasks than desired are written in red.
+func (e *ECS) convertPdf() {
+	header := []string{"Service", "Launch Type", "Desired / Running / Pending", "VPC", "Subnets"}
+	widths := []float64{40, 25, 25, 35, 65}
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	font := pdfFont(pdf, e.fontFile)
+	setPdfFooter(pdf, font)
+	pdf.AddPage()
+	pdf.SetFont(font, "", 8)
+	pdf.CellFormat(0, 10, fmt.Sprintf("Region: %s", e.manager.Region), "", 1, "L", false, 0, "")
+	printHeader := func() {
+		writePdfTableHeader(pdf, widths, header, 8)
+	}
+	for i, cl := range e.Clusters {
+		if i > 0 {
+			pdf.AddPage()
+		}
+		title := fmt.Sprintf("%s  (%d services, %d running tasks, %d pending tasks, %d standalone tasks)",
+			cl.Name, len(cl.Services), cl.RunningTasks, cl.PendingTasks, cl.StandaloneTasks())
+		pdf.CellFormat(0, 10, title, "1", 1, "C", false, 0, "")
+		printHeader()
+		for _, s := range cl.Services {
+			name := s.Name
+			if s.Status != "ACTIVE" {
+				name += fmt.Sprintf("\n(%s)", s.Status)
+			}
+			if s.IsUnderDesired() {
+				pdf.SetTextColor(220, 0, 0)
+			}
+			launchType := s.LaunchType
+			if s.IsAwsvpc() && s.AssignPublicIP {
+				launchType += "\npublic ip"
+			}
+			subnets, vpcs := e.subnetLabels(s)
+			counts := fmt.Sprintf("%d / %d / %d", s.DesiredCount, s.RunningCount, s.PendingCount)
+			row := []string{name, launchType, counts, strings.Join(vpcs, "\n"), strings.Join(subnets, "\n")}
+			writePdfMultiLineRow(pdf, widths, 5, row, printHeader)
+			pdf.SetTextColor(0, 0, 0)
+		}
+		if len(cl.Services) == 0 {
+			pdf.CellFormat(0, 8, "No Services", "1", 1, "C", false, 0, "")
+		}
+	}
+	if len(e.Clusters) == 0 {
+		pdf.CellFormat(0, 10, "No Clusters", "1", 0, "C", false, 0, "")
+	}
+	if err := pdf.OutputFileAndClose(outputPath("ecs.pdf")); err != nil {
+		e.stackError(err)
+	}
+}
+
+func nameWithID(name, id string) string {
+	if name == "" {
+		return id
+	}
+	return fmt.Sprintf("%s %s", name, id)
+}
+
+func (e *ECS) stackError(err error) *ECS {
+	util.LogError(err)
+	e.Errs = append(e.Errs, err)
+	return e
+}
+
+func (e *ECS) flattenErrs() error {
+	return svc.NewAggregateError(e.Errs)
+}
@@ -0,0 +1,7 @@
+package cmd
+
+import "github.com/atsushi-ishibashi/aws-state-report/svc"
+
+type Cluster = svc.Cluster
+
+type ECSService = svc.ECSService
@@ -0,0 +1,202 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/atsushi-ishibashi/aws-state-report/svc"
+	"github.com/atsushi-ishibashi/aws-state-report/util"
+	"github.com/aws/aws-sdk-go/service/elb"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/jung-kurt/gofpdf"
+	"github.com/urfave/cli"
+)
+
+func NewELBCommand() cli.Command {
+	return cli.Command{
+		Name:  "elb",
+		Usage: "export classic, application and network load balancers grouped by vpc in pdf file.",
+		Action: func(c *cli.Context) error {
+			if err := validateFontFile(c.GlobalString("font")); err != nil {
+				return util.ErrorRed(err.Error())
+			}
+			if err := util.ConfigAWS(c); err != nil {
+				return util.ErrorRed(err.Error())
+			}
+			ctx, cancel := withTimeout(c.GlobalDuration("timeout"))
+			defer cancel()
+			mng, err := svc.NewManager(ctx)
+			if err != nil {
+				return util.ErrorRed(err.Error())
+			}
+			e := &ELB{
+				manager:  mng,
+				fontFile: c.GlobalString("font"),
+				Errs:     make([]error, 0),
+			}
+			if err := e.recursiveConstruct(); err != nil {
+				return util.ErrorRed(err.Error())
+			}
+			if c.GlobalBool("dry-run") {
+				return nil
+			}
+			e.convertPdf()
+			if err := e.flattenErrs(); err != nil {
+				return util.ErrorRed(err.Error())
+			}
+			return nil
+		},
+	}
+}
+
+type ELB struct {
+	LoadBalancers []*LoadBalancer
+	manager       *svc.Manager
+	fontFile      string
+	Errs          []error
+}
+
+func (e *ELB) recursiveConstruct() error {
+	e.constructClassicLoadBalancers().
+		constructLoadBalancersV2()
+	return e.flattenErrs()
+}
+
+func (e *ELB) constructClassicLoadBalancers() *ELB {
+	result, err := e.manager.FetchClassicLoadBalancers()
+	if err != nil {
+		return e.stackError(err)
+	}
+	e.LoadBalancers = append(e.LoadBalancers, parseDescribeClassicLoadBalancersOutput(result)...)
+	return e
+}
+
+func (e *ELB) constructLoadBalancersV2() *ELB {
+	result, err := e.manager.FetchLoadBalancersV2()
+	if err != nil {
+		return e.stackError(err)
+	}
+	e.LoadBalancers = append(e.LoadBalancers, parseDescribeLoadBalancersV2Output(result)...)
+	return e
+}
+
+func (e *ELB) convertPdf() {
+	grouped := make(map[string][]*LoadBalancer)
+	for _, v := range e.LoadBalancers {
+		grouped[v.VpcID] = append(grouped[v.VpcID], v)
+	}
+	vpcIDs := make([]string, 0, len(grouped))
+	for vpcID := range grouped {
+		vpcIDs = append(vpcIDs, vpcID)
+	}
+	sort.Strings(vpcIDs)
+
+	header := []string{"Name", "Type", "Scheme", "Subnets", "Security Groups"}
+	widths := []float64{50, 20, 25, 50, 45}
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	font := pdfFont(pdf, e.fontFile)
+	setPdfFooter(pdf, font)
+	pdf.AddPage()
+	pdf.SetFont(font, "", 8)
+	pdf.CellFormat(0, 10, fmt.Sprintf("Region: %s", e.manager.Region), "", 1, "L", false, 0, "")
+	printHeader := func() {
+		writePdfTableHeader(pdf, widths, header, 8)
+	}
+	for i, vpcID := range vpcIDs {
+		if i > 0 {
+			pdf.AddPage()
+		}
+		title := vpcID
+		if title == "" {
+			title = "EC2-Classic"
+		}
+		pdf.CellFormat(0, 10, fmt.Sprintf("%s  (%d load balancers)", title, len(grouped[vpcID])), "1", 0, "C", false, 0, "")
+		pdf.Ln(-1)
+		printHeader()
+		for _, lb := range grouped[vpcID] {
+			row := []string{lb.Name, lb.Type, lb.Scheme, strings.Join(lb.Subnets, "\n"), strings.Join(lb.SecurityGroups, "\n")}
+			writePdfMultiLineRow(pdf, widths, 5, row, printHeader)
+		}
+	}
+	if len(vpcIDs) == 0 {
+		pdf.CellFormat(0, 10, "No Load Balancers", "1", 0, "C", false, 0, "")
+	}
+	if err := pdf.OutputFileAndClose(outputPath("elb.pdf")); err != nil {
+		e.stackError(err)
+	}
+}
+
+func (e *ELB) stackError(err error) *ELB {
+	util.LogError(err)
+	e.Errs = append(e.Errs, err)
+	return e
+}
+
+func (e *ELB) flattenErrs() error {
+	return svc.NewAggregateError(e.Errs)
+}
+
+func parseDescribeClassicLoadBalancersOutput(output *elb.DescribeLoadBalancersOutput) []*LoadBalancer {
+	lbs := make([]*LoadBalancer, 0)
+	for _, v := range output.LoadBalancerDescriptions {
+		if v.LoadBalancerName == nil {
+			continue
+		}
+		lb := &LoadBalancer{
+			Name:           *v.LoadBalancerName,
+			DNSName:        stringOrDash(v.DNSName),
+			Scheme:         stringOrDash(v.Scheme),
+			Type:           "classic",
+			Subnets:        make([]string, 0),
+			SecurityGroups: make([]string, 0),
+		}
+		if v.VPCId != nil {
+			lb.VpcID = *v.VPCId
+		}
+		for _, s := range v.Subnets {
+			if s != nil {
+				lb.Subnets = append(lb.Subnets, *s)
+			}
+		}
+		for _, g := range v.SecurityGroups {
+			if g != nil {
+				lb.SecurityGroups = append(lb.SecurityGroups, *g)
+			}
+		}
+		lbs = append(lbs, lb)
+	}
+	return lbs
+}
+
+func parseDescribeLoadBalancersV2Output(output *elbv2.DescribeLoadBalancersOutput) []*LoadBalancer {
+	lbs := make([]*LoadBalancer, 0)
+	for _, v := range output.LoadBalancers {
+		if v.LoadBalancerName == nil {
+			continue
+		}
+		lb := &LoadBalancer{
+			Name:           *v.LoadBalancerName,
+			DNSName:        stringOrDash(v.DNSName),
+			Scheme:         stringOrDash(v.Scheme),
+			Type:           stringOrDash(v.Type),
+			Subnets:        make([]string, 0),
+			SecurityGroups: make([]string, 0),
+		}
+		if v.VpcId != nil {
+			lb.VpcID = *v.VpcId
+		}
+		for _, az := range v.AvailabilityZones {
+			if az.SubnetId != nil {
+				lb.Subnets = append(lb.Subnets, *az.SubnetId)
+			}
+		}
+		for _, g := range v.SecurityGroups {
+			if g != nil {
+				lb.SecurityGroups = append(lb.SecurityGroups, *g)
+			}
+		}
+		lbs = append(lbs, lb)
+	}
+	return lbs
+}
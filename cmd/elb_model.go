@@ -0,0 +1,11 @@
+package cmd
+
+type LoadBalancer struct {
+	Name           string
+	DNSName        string
+	Scheme         string
+	Type           string
+	VpcID          string
+	Subnets        []string
+	SecurityGroups []string
+}
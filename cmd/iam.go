@@ -2,7 +2,6 @@ package cmd
 
 import (
 	"bytes"
-	"fmt"
 	"math"
 	"net/url"
 
@@ -28,7 +27,9 @@ func NewIAMCommand() cli.Command {
 			if err := util.ConfigAWS(c); err != nil {
 				return util.ErrorRed(err.Error())
 			}
-			mng, err := svc.NewManager()
+			ctx, cancel := withTimeout(c.GlobalDuration("timeout"))
+			defer cancel()
+			mng, err := svc.NewManager(ctx)
 			if err != nil {
 				return util.ErrorRed(err.Error())
 			}
@@ -39,6 +40,9 @@ func NewIAMCommand() cli.Command {
 			if err := iam.recursiveConstruct(); err != nil {
 				return util.ErrorRed(err.Error())
 			}
+			if c.GlobalBool("dry-run") {
+				return nil
+			}
 			iam.convertXlsx(c.String("src"))
 			return nil
 		},
@@ -164,19 +168,13 @@ func (iam *IAM) constructRoles() *IAM {
 }
 
 func (iam *IAM) stackError(err error) *IAM {
+	util.LogError(err)
 	iam.Errs = append(iam.Errs, err)
 	return iam
 }
 
 func (iam *IAM) flattenErrs() error {
-	if len(iam.Errs) == 0 {
-		return nil
-	}
-	var errStr string
-	for _, e := range iam.Errs {
-		errStr = errStr + e.Error() + "\n"
-	}
-	return fmt.Errorf(errStr)
+	return svc.NewAggregateError(iam.Errs)
 }
 
 func parseListPoliciesOutputToPolicies(output *iam.ListPoliciesOutput) []*Policy {
@@ -394,7 +392,7 @@ func (iam *IAM) convertXlsx(filename string) {
 		roleSheet.Cell(currentRoleRow, 1).SetStyle(borderWithAlign("t", false))
 		currentRoleRow++
 	}
-	if err := file.Save(fmt.Sprintf("./%s.xlsx", filename)); err != nil {
+	if err := file.Save(outputPath(filename + ".xlsx")); err != nil {
 		iam.stackError(err)
 	}
 }
@@ -1,12 +1,24 @@
 package cmd
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
 
 	"github.com/atsushi-ishibashi/aws-state-report/svc"
 	"github.com/atsushi-ishibashi/aws-state-report/util"
-	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/jung-kurt/gofpdf"
 	"github.com/tealeg/xlsx"
 	"github.com/urfave/cli"
@@ -24,100 +36,463 @@ func NewNetworkCommand() cli.Command {
 			},
 			cli.BoolFlag{
 				Name:  "pdf-mode",
-				Usage: "output in pdf file.",
+				Usage: "output in pdf file. same as --format pdf",
+			},
+			cli.StringFlag{
+				Name:  "format",
+				Usage: "output format. xlsx, pdf, json, both(pdf and json), dot, png, csv, html or md",
+				Value: "xlsx",
+			},
+			cli.StringFlag{
+				Name:  "output, o",
+				Usage: "pdf file path to export. - writes the report of any format to stdout",
+				Value: "./network.pdf",
+			},
+			cli.BoolFlag{
+				Name:  "stdout",
+				Usage: "write the report to stdout. same as --output -",
+			},
+			cli.BoolFlag{
+				Name:  "all-regions",
+				Usage: "export vpcs in all regions enabled for the account.",
+			},
+			cli.StringFlag{
+				Name:  "regions",
+				Usage: "export vpcs in the comma separated regions instead of all enabled regions, e.g. ap-northeast-1,us-east-1",
+			},
+			cli.BoolFlag{
+				Name:  "split-by-vpc",
+				Usage: "write vpc-<name>.pdf per vpc instead of one pdf. requires --format pdf or both",
+			},
+			cli.StringFlag{
+				Name:  "output-dir",
+				Usage: "directory to write the pdfs of --split-by-vpc instead of that of --output-prefix or the current directory",
+			},
+			cli.BoolFlag{
+				Name:  "title-page",
+				Usage: "add a title page and table of contents to pdf.",
+			},
+			cli.BoolFlag{
+				Name:  "landscape",
+				Usage: "output pdf in landscape orientation.",
+			},
+			cli.StringFlag{
+				Name:  "only",
+				Usage: "render only the comma separated sections of the pdf, e.g. route-tables,subnets. " + strings.Join(pdfSections, ", "),
+			},
+			cli.StringFlag{
+				Name:  "skip",
+				Usage: "skip the comma separated sections of the pdf, e.g. nacls. the resources only they show are not fetched",
+			},
+			cli.BoolFlag{
+				Name:  "compact",
+				Usage: "output pdf with 6mm rows and 8pt font to fit more on each page.",
+			},
+			cli.StringFlag{
+				Name:  "page-size",
+				Usage: "pdf page size. A4, A3 or Letter",
+				Value: "A4",
+			},
+			cli.StringSliceFlag{
+				Name:  "tag",
+				Usage: "export only vpcs and subnets with the tag. key=value, repeatable and ANDed",
+			},
+			cli.StringSliceFlag{
+				Name:  "vpc-id",
+				Usage: "export only the vpc. repeatable",
+			},
+			cli.DurationFlag{
+				Name:  "watch",
+				Usage: "regenerate the report every interval, e.g. 5m, overwriting the output until interrupted",
+			},
+			cli.StringFlag{
+				Name:  "show-tags",
+				Usage: "show the comma separated tags of vpcs and subnets in pdf, json and csv, e.g. Environment,Owner",
+			},
+			cli.BoolFlag{
+				Name:  "exclude-default-vpc",
+				Usage: "skip the default vpc.",
+			},
+			cli.BoolFlag{
+				Name:  "include-managed-prefix-lists",
+				Usage: "resolve the names and entries of prefix lists routes are destined to.",
+			},
+			cli.BoolFlag{
+				Name:  "include-network-interfaces",
+				Usage: "export the network interfaces of every subnet with what they are attached to.",
+			},
+			cli.BoolFlag{
+				Name:  "include-instance-counts",
+				Usage: "show the number of ec2 instances not terminated in every subnet.",
+			},
+			cli.BoolFlag{
+				Name:  "strict",
+				Usage: "fail without writing the report when any fetch fails.",
+			},
+			cli.BoolFlag{
+				Name:  "mask-account-id",
+				Usage: "replace account ids in the header and arns with XXXXXXXXXXXX.",
+			},
+			cli.BoolFlag{
+				Name:  "redact",
+				Usage: "mask account ids, instance ids and the last octet of ipv4 addresses and cidrs.",
+			},
+			cli.StringFlag{
+				Name:  "template",
+				Usage: "render html or md with the go template file instead of the built-in one",
+			},
+			cli.BoolFlag{
+				Name:  "json-compact",
+				Usage: "write the json without indentation.",
+			},
+			cli.StringFlag{
+				Name:  "upload-s3",
+				Usage: "upload the report to s3://bucket/prefix/ with a timestamped key",
 			},
 		},
 		Action: func(c *cli.Context) error {
-			if err := util.ConfigAWS(c); err != nil {
-				return util.ErrorRed(err.Error())
+			format := c.String("format")
+			if c.Bool("pdf-mode") {
+				format = "pdf"
+			}
+			switch format {
+			case "xlsx", "pdf", "json", "both", "dot", "png", "csv", "html", "md":
+			default:
+				return util.ErrorRed(fmt.Sprintf("unknown format: %s", format))
+			}
+			if format == "png" {
+				if err := validateGraphviz(); err != nil {
+					return util.ErrorRed(err.Error())
+				}
+			}
+			var regionSuffix string
+			if c.Bool("all-regions") {
+				regionSuffix = "all-regions"
+			}
+			if regions := splitList(c.String("regions")); len(regions) > 0 {
+				regionSuffix = strings.Join(regions, "_")
 			}
-			mng, err := svc.NewManager()
+			output := c.String("output")
+			if !c.IsSet("output") && OutputPrefix != "" {
+				output = regionOutputPath("network.pdf", regionSuffix)
+			}
+			if c.Bool("stdout") {
+				output = stdoutOutput
+			}
+			if output == stdoutOutput {
+				if format == "both" {
+					return util.ErrorRed("--format both cannot be written to stdout")
+				}
+				if c.String("upload-s3") != "" {
+					return util.ErrorRed("--upload-s3 cannot be used with stdout")
+				}
+				//keep stdout for the report
+				util.Stdout = os.Stderr
+			}
+			if watch := c.Duration("watch"); watch < 0 {
+				return util.ErrorRed("--watch must be a positive interval")
+			} else if watch > 0 && (output == stdoutOutput || c.GlobalBool("dry-run")) {
+				return util.ErrorRed("--watch cannot be used with stdout or --dry-run")
+			}
+			if c.Bool("split-by-vpc") {
+				if format != "pdf" && format != "both" {
+					return util.ErrorRed("--split-by-vpc requires --format pdf or both")
+				}
+				if output == stdoutOutput {
+					return util.ErrorRed("--split-by-vpc cannot be written to stdout")
+				}
+				//checks the directory is writable
+				if err := prepareOutputPath(splitOutputPath(c.String("output-dir"), "vpc.pdf")); err != nil {
+					return util.ErrorRed(err.Error())
+				}
+			} else if c.IsSet("output-dir") {
+				return util.ErrorRed("--output-dir is used with --split-by-vpc")
+			}
+			sections, err := parsePdfSections(c.String("only"), c.String("skip"))
 			if err != nil {
 				return util.ErrorRed(err.Error())
 			}
-			ntw := &Network{
-				manager: mng,
-				Errs:    make([]error, 0),
+			if sections != nil && format != "pdf" && format != "both" {
+				return util.ErrorRed("--only and --skip require --format pdf or both")
 			}
-			if err := ntw.recursiveConstruct(); err != nil {
+			if format == "pdf" || format == "both" {
+				if err := prepareOutputPath(output); err != nil {
+					return util.ErrorRed(err.Error())
+				}
+				if err := validateFontFile(c.GlobalString("font")); err != nil {
+					return util.ErrorRed(err.Error())
+				}
+				if err := validatePageSize(c.String("page-size")); err != nil {
+					return util.ErrorRed(err.Error())
+				}
+			}
+			if dest := c.String("upload-s3"); dest != "" {
+				if _, _, _, err := parseS3URL(dest); err != nil {
+					return util.ErrorRed(err.Error())
+				}
+			}
+			tagFilters, err := parseTagFilters(c.StringSlice("tag"))
+			if err != nil {
 				return util.ErrorRed(err.Error())
 			}
-			if c.Bool("pdf-mode") {
-				ntw.convertPdf()
-			} else {
-				ntw.convertXlsx(c.String("src"))
+			var tmpl reportTemplate
+			if path := c.String("template"); path != "" {
+				tmpl, err = parseReportTemplate(path, format)
+				if err != nil {
+					return util.ErrorRed(fmt.Sprintf("invalid template %s: %s", path, err))
+				}
+			}
+			//run writes a report. It is called every --watch interval with credentials configured again
+			//so that those of an assumed role do not expire.
+			//An interrupt of watch cancels parent and the report of the cycle is not written.
+			run := func(parent context.Context) error {
+				if err := util.ConfigAWS(c); err != nil {
+					return util.ErrorRed(err.Error())
+				}
+				ctx, cancel := withParentTimeout(parent, c.GlobalDuration("timeout"))
+				defer cancel()
+				mng, err := svc.NewManager(ctx)
+				if err != nil {
+					return util.ErrorRed(err.Error())
+				}
+				ntw := &Network{
+					manager:      mng,
+					output:       output,
+					fontFile:     c.GlobalString("font"),
+					titlePage:    c.Bool("title-page"),
+					landscape:    c.Bool("landscape"),
+					compact:      c.Bool("compact"),
+					jsonCompact:  c.Bool("json-compact"),
+					pageSize:     c.String("page-size"),
+					regionSuffix: regionSuffix,
+					splitByVpc:   c.Bool("split-by-vpc"),
+					outputDir:    c.String("output-dir"),
+					template:     tmpl,
+					options: svc.NetworkOptions{
+						TagFilters:                tagFilters,
+						VpcIDs:                    c.StringSlice("vpc-id"),
+						ExcludeDefaultVpc:         c.Bool("exclude-default-vpc"),
+						IncludeInstanceCounts:     c.Bool("include-instance-counts"),
+						ShowTags:                  splitList(c.String("show-tags")),
+						Progress:                  os.Stderr,
+						IncludeManagedPrefixLists: c.Bool("include-managed-prefix-lists"),
+						IncludeNetworkInterfaces:  c.Bool("include-network-interfaces") && sections.renders("network-interfaces"),
+						SkipNetworkAcls:           !sections.renders("nacls"),
+						SkipVpcEndpoints:          !sections.renders("endpoints"),
+						SkipFlowLogs:              !sections.renders("flow-logs"),
+						SkipDhcpOptions:           !sections.renders("dhcp"),
+					},
+					sections: sections,
+					Errs:     make([]error, 0),
+				}
+				construct := ntw.recursiveConstruct
+				if c.Bool("all-regions") {
+					construct = ntw.constructAllRegions
+				}
+				if regions := splitList(c.String("regions")); len(regions) > 0 {
+					construct = func() error {
+						return ntw.constructRegions(regions)
+					}
+				}
+				if err := construct(); err != nil && (c.Bool("strict") || c.GlobalBool("dry-run")) {
+					return util.ErrorRed(err.Error())
+				}
+				for _, v := range ntw.Vpcs {
+					for _, w := range v.HaWarnings {
+						util.PrintlnYellow(fmt.Sprintf("HA warning: %s: %s", v.ID, w))
+					}
+				}
+				if parent.Err() != nil {
+					return util.ErrorRed("interrupted. the report is not written")
+				}
+				if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+					ntw.stackError(fmt.Errorf("timed out after %s. the report has only the resources fetched until then", c.GlobalDuration("timeout")))
+				}
+				if c.GlobalBool("dry-run") {
+					return nil
+				}
+				//--timeout limits only the fetch. the account and the upload are resolved even after it expired.
+				ntw.manager = mng.WithContext(parent)
+				ntw.resolveAccount(c.GlobalString("account-alias"))
+				if c.Bool("redact") {
+					ntw.maskReport(redact)
+				} else if c.Bool("mask-account-id") {
+					ntw.maskReport(maskAccountID)
+				}
+				switch format {
+				case "pdf":
+					ntw.convertPdf()
+				case "json":
+					ntw.convertJSON()
+				case "both":
+					ntw.convertPdf()
+					ntw.convertJSON()
+				case "dot":
+					ntw.convertDot()
+				case "png":
+					ntw.convertPng()
+				case "csv":
+					ntw.convertCsv()
+				case "html":
+					ntw.convertHTML()
+				case "md":
+					ntw.convertMarkdown()
+				default:
+					ntw.convertXlsx(c.String("src"))
+				}
+				if dest := c.String("upload-s3"); dest != "" {
+					ntw.uploadS3(dest)
+				}
+				//the report of the vpcs fetched successfully has been written anyway
+				if err := ntw.flattenErrs(); err != nil {
+					return util.ErrorRed(err.Error())
+				}
+				return nil
+			}
+			if watch := c.Duration("watch"); watch > 0 {
+				return watchReport(watch, run)
 			}
-			return nil
+			return run(context.Background())
 		},
 	}
 }
 
+const stdoutOutput = "-"
+
 type Network struct {
-	Vpcs    []*Vpc
-	manager *svc.Manager
+	Vpcs      []*Vpc
+	Eips      []*Eip
+	manager   *svc.Manager
+	output    string
+	account   string
+	fontFile  string
+	titlePage bool
+	landscape bool
+	pageSize  string
+	//compact shrinks the rows and font of the pdf
+	compact     bool
+	jsonCompact bool
+	//sections are those of the pdf to render. nil renders all
+	sections pdfSectionSet
+	//splitByVpc writes a pdf per vpc into outputDir, or where OutputPrefix points when it is empty, instead of one to output
+	splitByVpc bool
+	outputDir  string
+	//regionSuffix is all-regions or the regions of --regions
+	regionSuffix string
+	template     reportTemplate
+	//mask is applied to the error messages in the json as well as the report
+	mask    func(string) string
+	options svc.NetworkOptions
+	written []string
 	Errs    []error
+	mu      sync.Mutex
 }
 
 func (nt *Network) recursiveConstruct() error {
-	nt.constructVpcs().
-		constructRouteTables().
-		constructSubnets().
-		associateRouteTableSubnet()
+	vpcs, err := svc.BuildNetworkModel(nt.manager, nt.options)
+	nt.Vpcs = vpcs
+	var agg *svc.AggregateError
+	if errors.As(err, &agg) {
+		//each error has been logged by BuildNetworkModel
+		nt.Errs = append(nt.Errs, agg.Errors()...)
+	} else if err != nil {
+		nt.Errs = append(nt.Errs, err)
+	}
+	if !nt.sections.renders("eips") {
+		return nt.flattenErrs()
+	}
+	if eips, err := svc.BuildEips(nt.manager); err != nil {
+		nt.stackError(err)
+	} else {
+		nt.Eips = eips
+	}
 	return nt.flattenErrs()
 }
 
-func (nt *Network) constructVpcs() *Network {
-	result, err := nt.manager.FetchVpcs()
+//resolveAccount sets the account label shown in the reports to the vpcs
+func (nt *Network) resolveAccount(alias string) {
+	account, err := accountLabel(nt.manager, alias)
 	if err != nil {
-		return nt.stackError(err)
+		nt.stackError(err)
+		account = "-"
 	}
-	nt.Vpcs = parseDescribeVpcsOutputToVpcs(result)
-	return nt
-}
-
-func (nt *Network) constructRouteTables() *Network {
-	for _, vpc := range nt.Vpcs {
-		if result, err := nt.manager.FetchRouteTablesWithVpc(vpc.ID); err != nil {
-			nt.stackError(err)
-		} else {
-			vpc.RouteTables = parseDescribeRouteTablesOutputToRouteTables(result)
-		}
+	nt.account = account
+	for _, v := range nt.Vpcs {
+		v.Account = account
 	}
-	return nt
 }
 
-func (nt *Network) constructSubnets() *Network {
-	for _, vpc := range nt.Vpcs {
-		if result, err := nt.manager.FetchSubnetsWithVpc(vpc.ID); err != nil {
-			nt.stackError(err)
-		} else {
-			vpc.Subnets = parseDescribeSubnetsOutputToSubnets(result)
+//constructAllRegions constructs the regions enabled for the account
+func (nt *Network) constructAllRegions() error {
+	result, err := nt.manager.FetchRegions()
+	if err != nil {
+		return nt.stackError(err).flattenErrs()
+	}
+	names := make([]string, 0, len(result.Regions))
+	for _, r := range result.Regions {
+		if r.RegionName != nil {
+			names = append(names, *r.RegionName)
 		}
 	}
-	return nt
+	return nt.constructRegions(names)
 }
 
-func (nt *Network) associateRouteTableSubnet() *Network {
-	for _, vpc := range nt.Vpcs {
-		for _, sn := range vpc.Subnets {
-			for _, rt := range vpc.RouteTables {
-				for _, rtas := range rt.AssociationSubnets {
-					if rtas == sn.ID {
-						sn.AssociatedRouteTable = rt
-					}
-				}
+func (nt *Network) constructRegions(regionNames []string) error {
+	//a failure in a region is recorded in its Network and does not stop the others
+	regions := make(map[string]*Network)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, svc.Concurrency)
+	options := nt.options
+	//the regions fetched concurrently would overwrite each other's progress updated in place
+	options.ProgressLines = len(regionNames) > 1
+	for _, r := range regionNames {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(region string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			rnt := &Network{
+				manager: nt.manager.WithRegion(region),
+				options: options,
+				Errs:    make([]error, 0),
 			}
+			rnt.recursiveConstruct()
+			mu.Lock()
+			regions[region] = rnt
+			mu.Unlock()
+		}(r)
+	}
+	wg.Wait()
+	names := make([]string, 0, len(regions))
+	for name := range regions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	vpcs := make([]*Vpc, 0)
+	eips := make([]*Eip, 0)
+	for _, name := range names {
+		rnt := regions[name]
+		for _, e := range rnt.Errs {
+			nt.Errs = append(nt.Errs, fmt.Errorf("%s: %w", name, e))
 		}
+		vpcs = append(vpcs, rnt.Vpcs...)
+		eips = append(eips, rnt.Eips...)
 	}
-	return nt
+	nt.Vpcs = vpcs
+	nt.Eips = eips
+	return nt.flattenErrs()
 }
 
 func (nt *Network) convertXlsx(filename string) {
 	file := xlsx.NewFile()
+	nt.addInventorySheets(file)
 	for _, v := range nt.Vpcs {
-		sheet, err := file.AddSheet(v.TagName)
+		sheet, err := file.AddSheet(vpcSheetName(file, v))
 		if err != nil {
-			util.PrintlnRed(err.Error())
+			nt.stackError(fmt.Errorf("%s: %w", v.ID, err))
 			continue
 		}
 		currentRow := 0
@@ -128,7 +503,7 @@ func (nt *Network) convertXlsx(filename string) {
 		currentRow++
 		for _, rt := range v.RouteTables {
 			rtCell := sheet.Cell(currentRow, 0)
-			rtCell.Value = fmt.Sprintf("Route Table: %s", rt.TagName)
+			rtCell.Value = fmt.Sprintf("Route Table: %s", routeTableTitle(rt))
 			rtCell.Merge(1, 0)
 			rtCell.SetStyle(borderWithAlign("lrtb", true))
 			snCell := sheet.Cell(currentRow, 2)
@@ -138,9 +513,9 @@ func (nt *Network) convertXlsx(filename string) {
 			currentRow++
 			var rtNo int
 			for _, rtr := range rt.Routes {
-				sheet.Cell(currentRow+rtNo, 0).Value = rtr.DestinationCidrBlock
+				sheet.Cell(currentRow+rtNo, 0).Value = rtr.Destination()
 				sheet.Cell(currentRow+rtNo, 0).SetStyle(borderWithAlign("l", false))
-				sheet.Cell(currentRow+rtNo, 1).Value = rtr.Router
+				sheet.Cell(currentRow+rtNo, 1).Value = rtr.Target()
 				sheet.Cell(currentRow+rtNo, 1).SetStyle(borderWithAlign("r", false))
 				rtNo++
 			}
@@ -164,7 +539,7 @@ func (nt *Network) convertXlsx(filename string) {
 		sheet.Cell(currentRow, 0).SetStyle(borderWithAlign("t", false))
 		sheet.Cell(currentRow, 1).SetStyle(borderWithAlign("t", false))
 		noaSnCell := sheet.Cell(currentRow, 2)
-		noaSnCell.Value = "No Association Subnets"
+		noaSnCell.Value = noAssociationTitle(v)
 		noaSnCell.Merge(1, 0)
 		noaSnCell.SetStyle(borderWithAlign("lrtb", true))
 		currentRow++
@@ -180,148 +555,846 @@ func (nt *Network) convertXlsx(filename string) {
 		sheet.Cell(currentRow, 2).SetStyle(borderWithAlign("t", false))
 		sheet.Cell(currentRow, 3).SetStyle(borderWithAlign("t", false))
 	}
-	if err := file.Save(fmt.Sprintf("./%s.xlsx", filename)); err != nil {
-		nt.stackError(err)
+	nt.writeReport(nt.outputPath(filename+".xlsx"), file.Write)
+}
+
+//rowHeight is the height of the rows of the pdf, from which the headers and the lines of multi-line rows are derived
+func (nt *Network) rowHeight() float64 {
+	if nt.compact {
+		return 6
 	}
+	return 10
+}
+
+func (nt *Network) headerHeight() float64 {
+	return nt.rowHeight() * 0.8
+}
+
+func (nt *Network) lineHeight() float64 {
+	return nt.rowHeight() * 0.6
+}
+
+func (nt *Network) fontSize() float64 {
+	if nt.compact {
+		return 8
+	}
+	return 10
+}
+
+func (nt *Network) newPdf() (*gofpdf.Fpdf, string) {
+	orientation := "P"
+	if nt.landscape {
+		orientation = "L"
+	}
+	pdf := gofpdf.New(orientation, "mm", nt.pageSize, "")
+	font := pdfFont(pdf, nt.fontFile)
+	setPdfHeader(pdf, font, nt.account)
+	setPdfFooter(pdf, font)
+	return pdf, font
 }
 
 func (nt *Network) convertPdf() {
-	pdf := gofpdf.New("P", "mm", "A4", "")
-	pdf.AddPage()
-	pdf.SetFont("Arial", "", 10)
+	rowHeight := nt.rowHeight()
+	if nt.splitByVpc {
+		nt.convertPdfPerVpc()
+		return
+	}
+	pdf, font := nt.newPdf()
+	var toc *pdfToc
+	if nt.titlePage {
+		toc = nt.renderTitlePage(pdf, font)
+	}
+	pdf.SetFont(font, "", nt.fontSize())
+	if len(nt.Vpcs) == 0 {
+		pdf.AddPage()
+		pdf.CellFormat(0, rowHeight, fmt.Sprintf("Region: %s", nt.manager.Region), "", 1, "L", false, 0, "")
+	} else if nt.sections.renders("summary") {
+		pdf.AddPage()
+		nt.convertSummaryToPdf(pdf, toc)
+	}
+	for i, v := range nt.Vpcs {
+		pdf.AddPage()
+		if i == 0 || v.Region != nt.Vpcs[i-1].Region {
+			pdf.CellFormat(0, rowHeight, fmt.Sprintf("Region: %s", v.Region), "", 1, "L", false, 0, "")
+		}
+		if toc != nil {
+			toc.mark(fmt.Sprintf("%s  %s  %s", v.Region, v.TagName, v.ID))
+		}
+		nt.convertVpcToPdf(pdf, v)
+	}
+	if nt.sections.renders("transit-gateways") {
+		nt.convertTransitGatewaysToPdf(pdf, toc)
+	}
+	if nt.sections.renders("peering") {
+		nt.convertPeeringConnectionsToPdf(pdf, toc)
+	}
+	if nt.sections.renders("eips") {
+		nt.convertEipsToPdf(pdf, toc)
+	}
+	if toc != nil {
+		toc.render()
+	}
+	nt.writeReport(nt.output, pdf.Output)
+}
+
+//convertPdfPerVpc writes vpc-<name>.pdf per vpc, and account.pdf with the transit gateways,
+//peering connections and elastic ips shared by the vpcs unless there is none of them
+func (nt *Network) convertPdfPerVpc() {
+	rowHeight := nt.rowHeight()
+	names := vpcFileNames(nt.Vpcs)
 	for _, v := range nt.Vpcs {
-		pdf.CellFormat(0, 10, fmt.Sprintf("%s  %s", v.TagName, v.CidrBlock), "1", 0, "C", false, 0, "")
-		pdf.Ln(-1)
-		for _, rt := range v.RouteTables {
-			pdf.CellFormat(95, 10, fmt.Sprintf("%s", rt.TagName), "1", 0, "C", false, 0, "")
-			pdf.CellFormat(95, 10, "Association Subnets", "1", 0, "C", false, 0, "")
-			pdf.Ln(-1)
-			currentX, currentY := pdf.GetXY()
-			var rtHeight float64
-			for _, rtr := range rt.Routes {
-				pdf.MoveTo(currentX, currentY+rtHeight)
-				pdf.CellFormat(95, 10, fmt.Sprintf("%s %s", rtr.DestinationCidrBlock, rtr.Router), "RL", 0, "C", false, 0, "")
-				rtHeight += 10.0
+		pdf, font := nt.newPdf()
+		pdf.AddPage()
+		pdf.SetFont(font, "", nt.fontSize())
+		pdf.CellFormat(0, rowHeight, fmt.Sprintf("Region: %s", v.Region), "", 1, "L", false, 0, "")
+		nt.convertVpcToPdf(pdf, v)
+		nt.writeReport(splitOutputPath(nt.outputDir, fmt.Sprintf("vpc-%s.pdf", names[v])), pdf.Output)
+	}
+	pdf, font := nt.newPdf()
+	pdf.SetFont(font, "", nt.fontSize())
+	if nt.sections.renders("transit-gateways") {
+		nt.convertTransitGatewaysToPdf(pdf, nil)
+	}
+	if nt.sections.renders("peering") {
+		nt.convertPeeringConnectionsToPdf(pdf, nil)
+	}
+	if nt.sections.renders("eips") {
+		nt.convertEipsToPdf(pdf, nil)
+	}
+	if pdf.PageNo() > 0 {
+		nt.writeReport(splitOutputPath(nt.outputDir, "account.pdf"), pdf.Output)
+	}
+}
+
+//splitOutputPath returns the path of a pdf of --split-by-vpc. The file name part of OutputPrefix is kept in dir.
+func splitOutputPath(dir, name string) string {
+	if dir == "" {
+		return outputPath(name)
+	}
+	_, prefix := filepath.Split(OutputPrefix)
+	return filepath.Join(dir, prefix+name)
+}
+
+//vpcFileNames returns the tag names, or the ids of vpcs without one, usable as file names.
+//The id is appended when vpcs share a name.
+func vpcFileNames(vpcs []*Vpc) map[*Vpc]string {
+	names := make(map[*Vpc]string)
+	count := make(map[string]int)
+	for _, v := range vpcs {
+		name := strings.Map(func(r rune) rune {
+			if strings.ContainsRune(`/\:*?"<>|`, r) || unicode.IsSpace(r) || !unicode.IsPrint(r) {
+				return '_'
 			}
-			var snHeight float64
-			for _, sn := range v.Subnets {
-				if sn.AssociatedRouteTable == rt {
-					pdf.MoveTo(currentX+95, currentY+snHeight)
-					pdf.CellFormat(95, 10, fmt.Sprintf("%s %s", sn.TagName, sn.CidrBlock), "RL", 0, "C", false, 0, "")
-					snHeight += 10.0
-				}
+			return r
+		}, v.TagName)
+		if name == "" {
+			name = v.ID
+		}
+		names[v] = name
+		count[name]++
+	}
+	for v, name := range names {
+		if count[name] > 1 && name != v.ID {
+			names[v] = fmt.Sprintf("%s-%s", name, v.ID)
+		}
+	}
+	return names
+}
+
+//convertVpcToPdf writes the header, route tables with their subnets, endpoints, network interfaces and network acls of the vpc
+func (nt *Network) convertVpcToPdf(pdf *gofpdf.Fpdf, v *Vpc) {
+	rowHeight, lineHeight := nt.rowHeight(), nt.lineHeight()
+	vpcTitle := fmt.Sprintf("  %s", v.CidrBlock)
+	if v.IsDefault {
+		vpcTitle += "  (default vpc)"
+	}
+	if tags := nt.tagsLabel(v.Tags); tags != "" {
+		vpcTitle += "  " + tags
+	}
+	vpcTitle = fitPdfName(pdf, v.TagName, vpcTitle, pdfContentWidth(pdf))
+	if len(v.Ipv6CidrBlocks) == 0 {
+		pdf.CellFormat(0, rowHeight, vpcTitle, "1", 1, "C", false, 0, "")
+	} else {
+		pdf.CellFormat(0, rowHeight, vpcTitle, "LRT", 1, "C", false, 0, "")
+		pdf.CellFormat(0, lineHeight, strings.Join(v.Ipv6CidrBlocks, "  "), "LRB", 1, "C", false, 0, "")
+	}
+	if v.DhcpOptions != nil && nt.sections.renders("dhcp") {
+		pdf.CellFormat(0, lineHeight, dhcpOptionsLabel(v.DhcpOptions), "1", 1, "C", false, 0, "")
+	}
+	if nt.sections.renders("flow-logs") {
+		flowLogsBadgePdf(pdf, v)
+	}
+	if len(v.HaWarnings) > 0 {
+		pdf.SetFillColor(255, 230, 150)
+		for _, w := range v.HaWarnings {
+			pdf.CellFormat(0, lineHeight, fitPdfText(pdf, "HA warning: "+w, pdfContentWidth(pdf)), "1", 1, "C", true, 0, "")
+		}
+	}
+	if len(v.Subnets) == 0 {
+		pdf.CellFormat(0, rowHeight, "No subnets", "1", 1, "C", false, 0, "")
+	} else if nt.sections.renders("route-tables") || nt.sections.renders("subnets") {
+		subnetLegendPdf(pdf)
+	}
+	if nt.sections.renders("route-tables") {
+		nt.convertRouteTablesToPdf(pdf, v)
+	}
+	if len(v.Subnets) > 0 && nt.sections.renders("subnets") {
+		nt.convertNoAssociationSubnetsToPdf(pdf, v)
+	}
+	if len(v.VpcEndpoints) > 0 && nt.sections.renders("endpoints") {
+		nt.convertVpcEndpointsToPdf(pdf, v)
+	}
+	if nt.sections.renders("network-interfaces") {
+		for _, sn := range v.Subnets {
+			if len(sn.Enis) > 0 {
+				nt.convertEnisToPdf(pdf, sn)
 			}
-			maxHeight := math.Max(snHeight, rtHeight)
-			pdf.MoveTo(currentX, currentY)
-			pdf.CellFormat(0, maxHeight, "", "1", 0, "C", false, 0, "")
+		}
+	}
+	if nt.sections.renders("nacls") {
+		for _, acl := range v.NetworkAcls {
+			nt.convertNetworkAclToPdf(pdf, v, acl)
+		}
+	}
+}
+
+//convertRouteTablesToPdf writes the routes of each route table side by side with its association subnets
+func (nt *Network) convertRouteTablesToPdf(pdf *gofpdf.Fpdf, v *Vpc) {
+	rowHeight, lineHeight := nt.rowHeight(), nt.lineHeight()
+	half := pdfContentWidth(pdf) / 2
+	destWidth := half * 0.4
+	for _, rt := range v.RouteTables {
+		rtHeader := func() {
+			pdf.CellFormat(half, rowHeight, fitPdfName(pdf, rt.TagName, strings.TrimPrefix(routeTableTitle(rt), rt.TagName), half), "1", 0, "C", false, 0, "")
+			pdf.CellFormat(half, rowHeight, "Association Subnets", "1", 0, "C", false, 0, "")
 			pdf.Ln(-1)
+			pdf.CellFormat(destWidth, lineHeight, "Destination", "1", 0, "C", false, 0, "")
+			pdf.CellFormat(half-destWidth, lineHeight, "Target", "1", 0, "C", false, 0, "")
+			pdf.CellFormat(half, lineHeight, "Subnet  CIDR  AZ", "1", 1, "C", false, 0, "")
 		}
-		pdf.CellFormat(0, 10, "No Association Subnets", "1", 0, "C", false, 0, "")
-		pdf.Ln(-1)
-		currentX, currentY := pdf.GetXY()
-		var noaSnHeight float64
+		rtHeader()
+		sns := make([]*Subnet, 0)
 		for _, sn := range v.Subnets {
-			if sn.AssociatedRouteTable == nil {
-				pdf.CellFormat(0, 10, fmt.Sprintf("%s %s", sn.TagName, sn.CidrBlock), "LR", 0, "C", false, 0, "")
-				pdf.Ln(-1)
-				noaSnHeight += 10
+			if sn.AssociatedRouteTable == rt {
+				sns = append(sns, sn)
 			}
 		}
-		pdf.MoveTo(currentX, currentY)
-		pdf.CellFormat(0, noaSnHeight, "", "1", 0, "C", false, 0, "")
-		pdf.AddPage()
+		maxNo := int(math.Max(float64(len(rt.Routes)), float64(len(sns))))
+		if maxNo == 0 {
+			continue
+		}
+		for i := 0; i < maxNo; i++ {
+			if breakPdfPage(pdf, rowHeight) {
+				rtHeader()
+			}
+			var dest, target string
+			if i < len(rt.Routes) {
+				dest, target = rt.Routes[i].Destination(), rt.Routes[i].Target()
+			}
+			pdf.CellFormat(destWidth, rowHeight, fitPdfText(pdf, dest, destWidth), "LR", 0, "C", false, 0, "")
+			pdf.CellFormat(half-destWidth, rowHeight, fitPdfText(pdf, target, half-destWidth), "LR", 0, "C", false, 0, "")
+			if i < len(sns) {
+				nt.subnetPdfCell(pdf, half, rowHeight, sns[i])
+			} else {
+				pdf.CellFormat(half, rowHeight, "", "LR", 1, "C", false, 0, "")
+			}
+		}
+		pdf.CellFormat(0, 0, "", "T", 1, "C", false, 0, "")
 	}
-	if err := pdf.OutputFileAndClose("./network.pdf"); err != nil {
-		nt.stackError(err)
+}
+
+func (nt *Network) convertNoAssociationSubnetsToPdf(pdf *gofpdf.Fpdf, v *Vpc) {
+	rowHeight := nt.rowHeight()
+	noaSnHeader := func() {
+		pdf.CellFormat(0, rowHeight, fitPdfText(pdf, noAssociationTitle(v), pdfContentWidth(pdf)), "1", 0, "C", false, 0, "")
+		pdf.Ln(-1)
+	}
+	noaSnHeader()
+	for _, sn := range v.Subnets {
+		if sn.AssociatedRouteTable == nil {
+			if breakPdfPage(pdf, rowHeight) {
+				noaSnHeader()
+			}
+			nt.subnetPdfCell(pdf, 0, rowHeight, sn)
+		}
 	}
+	pdf.CellFormat(0, 0, "", "T", 1, "C", false, 0, "")
 }
 
-func (nt *Network) stackError(err error) *Network {
-	nt.Errs = append(nt.Errs, err)
-	return nt
+//flowLogsBadgePdf prints a green ON or red OFF badge with the destinations of active flow logs
+func flowLogsBadgePdf(pdf *gofpdf.Fpdf, v *Vpc) {
+	label := flowLogsLabel(v)
+	switch {
+	case v.FlowLogs == nil:
+		pdf.SetFillColor(220, 220, 220)
+	case v.FlowLogsEnabled():
+		pdf.SetFillColor(200, 240, 200)
+	default:
+		pdf.SetFillColor(255, 150, 150)
+	}
+	pdf.CellFormat(0, 6, label, "1", 1, "C", true, 0, "")
 }
 
-func (nt *Network) flattenErrs() error {
-	if len(nt.Errs) == 0 {
-		return nil
+func flowLogsLabel(v *Vpc) string {
+	if v.FlowLogs == nil {
+		return "Flow Logs: unknown"
 	}
-	var errStr string
-	for _, e := range nt.Errs {
-		errStr = errStr + e.Error() + "\n"
+	if !v.FlowLogsEnabled() {
+		return "Flow Logs: OFF"
 	}
-	return fmt.Errorf(errStr)
+	dests := make([]string, 0)
+	for _, fl := range v.FlowLogs {
+		if fl.Status == "ACTIVE" {
+			dests = append(dests, fmt.Sprintf("%s %s (%s)", fl.DestinationType, fl.Destination, fl.TrafficType))
+		}
+	}
+	return "Flow Logs: ON  " + strings.Join(dests, ", ")
 }
 
-func parseDescribeVpcsOutputToVpcs(output *ec2.DescribeVpcsOutput) []*Vpc {
-	vs := make([]*Vpc, 0)
-	for _, v := range output.Vpcs {
-		vpc := &Vpc{
-			ID:        *v.VpcId,
-			TagName:   extractTagName(v.Tags),
-			CidrBlock: *v.CidrBlock,
+//flowLogsClass is the css class of the flow logs badge in html, unknown when they could not be fetched
+func flowLogsClass(v *Vpc) string {
+	switch {
+	case v.FlowLogs == nil:
+		return "unknown"
+	case v.FlowLogsEnabled():
+		return "on"
+	default:
+		return "off"
+	}
+}
+
+//dhcpOptionsLabel shows the domain name, dns and ntp servers of the set
+func dhcpOptionsLabel(opts *DhcpOptions) string {
+	dns := strings.Join(opts.DomainNameServers, ", ")
+	if dns == "" {
+		dns = "-"
+	}
+	ntp := strings.Join(opts.NtpServers, ", ")
+	if ntp == "" {
+		ntp = "-"
+	}
+	domain := opts.DomainName
+	if domain == "" {
+		domain = "-"
+	}
+	return fmt.Sprintf("DHCP %s  domain: %s  dns: %s  ntp: %s", opts.ID, domain, dns, ntp)
+}
+
+func routeTableTitle(rt *RouteTable) string {
+	if rt.IsMain {
+		return rt.TagName + " (main)"
+	}
+	return rt.TagName
+}
+
+//noAssociationTitle tells that the subnets without explicit association are governed by the main route table
+func noAssociationTitle(v *Vpc) string {
+	main := v.MainRouteTable()
+	if main == nil {
+		return "No Association Subnets"
+	}
+	return fmt.Sprintf("No Association Subnets (main route table: %s %s)", main.TagName, main.ID)
+}
+
+//subnetPdfCell writes a subnet cell of height h followed by a line break.
+//IPv6 cidrs, if any, are printed under the IPv4 cidr.
+//A subnet whose cidr overlaps another is outlined in red.
+func (nt *Network) subnetPdfCell(pdf *gofpdf.Fpdf, w, h float64, sn *Subnet) {
+	setSubnetFillColor(pdf, sn.IsPublic())
+	if sn.IsNearlyFull() {
+		pdf.SetTextColor(220, 0, 0)
+		defer pdf.SetTextColor(0, 0, 0)
+	}
+	x, y := pdf.GetXY()
+	if w == 0 {
+		left, _, _, _ := pdf.GetMargins()
+		w = pdfContentWidth(pdf) - (x - left)
+	}
+	text := fmt.Sprintf(" %s %s %s", sn.CidrBlock, subnetZone(sn), sn.UtilizationLabel())
+	if sn.InstanceCount != nil {
+		text += fmt.Sprintf(" [%d instances]", *sn.InstanceCount)
+	}
+	if tags := nt.tagsLabel(sn.Tags); tags != "" {
+		text += " " + tags
+	}
+	text = fitPdfName(pdf, sn.TagName, text, w)
+	if len(sn.Ipv6CidrBlocks) == 0 {
+		pdf.CellFormat(w, h, text, "LR", 1, "C", true, 0, "")
+	} else {
+		pdf.CellFormat(w, h/2, text, "LR", 2, "C", true, 0, "")
+		pdf.CellFormat(w, h/2, fitPdfText(pdf, strings.Join(sn.Ipv6CidrBlocks, " "), w), "LR", 1, "C", true, 0, "")
+	}
+	if len(sn.OverlappingSubnets) > 0 {
+		outlineOverlapPdf(pdf, x, y, w, h)
+	}
+}
+
+//tagsLabel returns the tags of --show-tags as key=value in the order given
+func (nt *Network) tagsLabel(tags map[string]string) string {
+	kvs := make([]string, 0, len(tags))
+	for _, k := range nt.options.ShowTags {
+		if v, ok := tags[k]; ok {
+			kvs = append(kvs, fmt.Sprintf("%s=%s", k, v))
 		}
-		acbs := make([]string, 0)
-		for _, cbs := range v.CidrBlockAssociationSet {
-			acbs = append(acbs, *cbs.CidrBlock)
+	}
+	return strings.Join(kvs, " ")
+}
+
+func outlineOverlapPdf(pdf *gofpdf.Fpdf, x, y, w, h float64) {
+	lineWidth := pdf.GetLineWidth()
+	pdf.SetDrawColor(220, 0, 0)
+	pdf.SetLineWidth(0.6)
+	pdf.Rect(x+0.3, y+0.3, w-0.6, h-0.6, "D")
+	pdf.SetDrawColor(0, 0, 0)
+	pdf.SetLineWidth(lineWidth)
+}
+
+//setSubnetFillColor sets green for public subnets and blue for private ones
+func setSubnetFillColor(pdf *gofpdf.Fpdf, public bool) {
+	if public {
+		pdf.SetFillColor(200, 240, 200)
+	} else {
+		pdf.SetFillColor(200, 220, 245)
+	}
+}
+
+func subnetLegendPdf(pdf *gofpdf.Fpdf) {
+	setSubnetFillColor(pdf, true)
+	pdf.CellFormat(6, 5, "", "1", 0, "C", true, 0, "")
+	pdf.CellFormat(30, 5, " public subnet", "", 0, "L", false, 0, "")
+	setSubnetFillColor(pdf, false)
+	pdf.CellFormat(6, 5, "", "1", 0, "C", true, 0, "")
+	pdf.CellFormat(30, 5, " private subnet", "", 0, "L", false, 0, "")
+	x, y := pdf.GetXY()
+	pdf.CellFormat(6, 5, "", "", 0, "C", false, 0, "")
+	outlineOverlapPdf(pdf, x, y, 6, 5)
+	pdf.CellFormat(40, 5, " overlapping cidr", "", 0, "L", false, 0, "")
+	pdf.SetTextColor(220, 0, 0)
+	pdf.CellFormat(40, 5, fmt.Sprintf(" over %.0f%% used", svc.NearlyFullUtilization*100), "", 1, "L", false, 0, "")
+	pdf.SetTextColor(0, 0, 0)
+}
+
+func subnetZone(sn *Subnet) string {
+	if sn.AvailabilityZoneID == "" {
+		return fmt.Sprintf("(%s)", sn.AvailabilityZone)
+	}
+	return fmt.Sprintf("(%s / %s)", sn.AvailabilityZone, sn.AvailabilityZoneID)
+}
+
+//renderTitlePage adds a title page with account, regions and generation time,
+//and reserves pages for the table of contents which is rendered after all vpcs.
+func (nt *Network) renderTitlePage(pdf *gofpdf.Fpdf, font string) *pdfToc {
+	regions := make([]string, 0)
+	for i, v := range nt.Vpcs {
+		if i == 0 || v.Region != nt.Vpcs[i-1].Region {
+			regions = append(regions, v.Region)
 		}
-		vpc.AssociatedCidrBlocks = acbs
-		vs = append(vs, vpc)
 	}
-	return vs
+	if len(regions) == 0 {
+		regions = append(regions, nt.manager.Region)
+	}
+	pdf.AddPage()
+	pdf.SetFont(font, "B", 20)
+	pdf.Ln(60)
+	pdf.CellFormat(0, 15, "Network Report", "", 1, "C", false, 0, "")
+	pdf.SetFont(font, "", 12)
+	pdf.Ln(10)
+	pdf.CellFormat(0, 10, fmt.Sprintf("Account: %s", nt.account), "", 1, "C", false, 0, "")
+	pdf.CellFormat(0, 10, fmt.Sprintf("Region: %s", strings.Join(regions, ", ")), "", 1, "C", false, 0, "")
+	pdf.CellFormat(0, 10, fmt.Sprintf("Generated at: %s", time.Now().UTC().Format(time.RFC3339)), "", 1, "C", false, 0, "")
+	//the vpcs, the summary, transit gateways, peering connections and elastic ips
+	return newPdfToc(pdf, font, len(nt.Vpcs)+4)
 }
 
-func parseDescribeRouteTablesOutputToRouteTables(output *ec2.DescribeRouteTablesOutput) []*RouteTable {
-	rts := make([]*RouteTable, 0)
-	for _, v := range output.RouteTables {
-		rt := &RouteTable{
-			ID:      *v.RouteTableId,
-			TagName: extractTagName(v.Tags),
+//convertVpcEndpointsToPdf lists the route tables of gateway endpoints by the names shown above, and the subnets of the others
+func (nt *Network) convertVpcEndpointsToPdf(pdf *gofpdf.Fpdf, vpc *Vpc) {
+	rowHeight, headerHeight, lineHeight := nt.rowHeight(), nt.headerHeight(), nt.lineHeight()
+	names := make(map[string]string)
+	for _, rt := range vpc.RouteTables {
+		names[rt.ID] = fmt.Sprintf("%s %s", rt.TagName, rt.ID)
+	}
+	for _, sn := range vpc.Subnets {
+		names[sn.ID] = fmt.Sprintf("%s %s", sn.TagName, sn.ID)
+	}
+	widths := scalePdfWidths(pdf, []float64{75, 30, 85})
+	header := func() {
+		pdf.CellFormat(0, rowHeight, "VPC Endpoints", "1", 1, "C", false, 0, "")
+		pdf.CellFormat(widths[0], headerHeight, "Service", "1", 0, "C", false, 0, "")
+		pdf.CellFormat(widths[1], headerHeight, "Type", "1", 0, "C", false, 0, "")
+		pdf.CellFormat(widths[2], headerHeight, "Route Tables / Subnets", "1", 1, "C", false, 0, "")
+	}
+	breakPdfPage(pdf, 26)
+	header()
+	for _, ep := range vpc.VpcEndpoints {
+		ids := ep.Subnets
+		if ep.Type == "Gateway" {
+			ids = ep.RouteTables
 		}
-		rs := make([]*Route, 0)
-		for _, r := range v.Routes {
-			if r.DestinationCidrBlock == nil {
-				continue
-			}
-			rr := &Route{
-				DestinationCidrBlock: *r.DestinationCidrBlock,
-			}
-			var routerID string
-			if r.GatewayId != nil {
-				routerID = *r.GatewayId
+		targets := make([]string, 0, len(ids))
+		for _, id := range ids {
+			if name, ok := names[id]; ok {
+				targets = append(targets, name)
+			} else {
+				targets = append(targets, id)
 			}
-			if r.NatGatewayId != nil {
-				routerID = *r.NatGatewayId
+		}
+		writePdfMultiLineRow(pdf, widths, lineHeight, []string{fmt.Sprintf("%s\n%s", ep.ServiceName, ep.ID), ep.Type, strings.Join(targets, "\n")}, header)
+	}
+}
+
+//convertSummaryToPdf lists the vpcs with the counts of their subnets and route tables
+func (nt *Network) convertSummaryToPdf(pdf *gofpdf.Fpdf, toc *pdfToc) {
+	rowHeight, headerHeight, lineHeight := nt.rowHeight(), nt.headerHeight(), nt.lineHeight()
+	if toc != nil {
+		toc.mark("Summary")
+	}
+	widths := scalePdfWidths(pdf, []float64{30, 55, 35, 20, 20, 15, 15})
+	header := func() {
+		pdf.CellFormat(0, rowHeight, "Summary", "1", 1, "C", false, 0, "")
+		writePdfTableHeader(pdf, widths, []string{"Region", "VPC", "CIDR", "Subnets", "Route Tables", "Public", "Private"}, headerHeight)
+	}
+	header()
+	var totalSns, totalRts, totalPublic int
+	for _, v := range nt.Vpcs {
+		public := 0
+		for _, sn := range v.Subnets {
+			if sn.IsPublic() {
+				public++
 			}
-			if r.VpcPeeringConnectionId != nil {
-				routerID = *r.VpcPeeringConnectionId
+		}
+		totalSns += len(v.Subnets)
+		totalRts += len(v.RouteTables)
+		totalPublic += public
+		writePdfMultiLineRow(pdf, widths, lineHeight, []string{
+			v.Region,
+			strings.TrimSpace(fmt.Sprintf("%s\n%s", v.TagName, v.ID)),
+			v.CidrBlock,
+			strconv.Itoa(len(v.Subnets)),
+			strconv.Itoa(len(v.RouteTables)),
+			strconv.Itoa(public),
+			strconv.Itoa(len(v.Subnets) - public),
+		}, header)
+	}
+	if breakPdfPage(pdf, headerHeight) {
+		header()
+	}
+	total := []string{"Total", fmt.Sprintf("%d vpcs", len(nt.Vpcs)), "", strconv.Itoa(totalSns), strconv.Itoa(totalRts), strconv.Itoa(totalPublic), strconv.Itoa(totalSns - totalPublic)}
+	for i, col := range total {
+		pdf.CellFormat(widths[i], headerHeight, col, "1", 0, "C", false, 0, "")
+	}
+	pdf.Ln(-1)
+}
+
+func (nt *Network) convertEnisToPdf(pdf *gofpdf.Fpdf, sn *Subnet) {
+	rowHeight, headerHeight, lineHeight := nt.rowHeight(), nt.headerHeight(), nt.lineHeight()
+	title := fmt.Sprintf("Network Interfaces: %s %s %s", sn.TagName, sn.ID, sn.CidrBlock)
+	widths := scalePdfWidths(pdf, []float64{45, 30, 25, 40, 50})
+	header := func() {
+		pdf.CellFormat(0, rowHeight, title, "1", 1, "C", false, 0, "")
+		writePdfTableHeader(pdf, widths, []string{"Interface", "Private IP", "Type", "Security Groups", "Description"}, headerHeight)
+	}
+	breakPdfPage(pdf, 26)
+	header()
+	for _, eni := range sn.Enis {
+		writePdfMultiLineRow(pdf, widths, lineHeight, []string{
+			strings.TrimSpace(fmt.Sprintf("%s\n%s", eni.TagName, eni.ID)),
+			strings.Join(eni.PrivateIPs, "\n"),
+			eni.AttachmentType,
+			strings.Join(eni.SecurityGroups, "\n"),
+			eni.Description,
+		}, header)
+	}
+}
+
+//convertTransitGatewaysToPdf lists the vpc attachments grouped by transit gateway on a new page
+func (nt *Network) convertTransitGatewaysToPdf(pdf *gofpdf.Fpdf, toc *pdfToc) {
+	rowHeight, headerHeight, lineHeight := nt.rowHeight(), nt.headerHeight(), nt.lineHeight()
+	tgwIDs := make([]string, 0)
+	tgwNames := make(map[string]string)
+	attachments := make(map[string][]*TransitGatewayAttachment)
+	vpcs := make(map[*TransitGatewayAttachment]*Vpc)
+	for _, v := range nt.Vpcs {
+		for _, a := range v.TransitGatewayAttachments {
+			if _, ok := attachments[a.TransitGatewayID]; !ok {
+				tgwIDs = append(tgwIDs, a.TransitGatewayID)
+				tgwNames[a.TransitGatewayID] = a.TransitGatewayName
 			}
-			rr.Router = routerID
-			rs = append(rs, rr)
+			attachments[a.TransitGatewayID] = append(attachments[a.TransitGatewayID], a)
+			vpcs[a] = v
 		}
-		rt.Routes = rs
-		asSubnets := make([]string, 0)
-		for _, as := range v.Associations {
-			if as.SubnetId != nil {
-				asSubnets = append(asSubnets, *as.SubnetId)
-			} else {
-				asSubnets = append(asSubnets, "implicit")
+	}
+	if len(tgwIDs) == 0 {
+		return
+	}
+	sort.Strings(tgwIDs)
+	pdf.AddPage()
+	if toc != nil {
+		toc.mark("Transit Gateways")
+	}
+	pdf.CellFormat(0, rowHeight, "Transit Gateways", "", 1, "L", false, 0, "")
+	widths := scalePdfWidths(pdf, []float64{60, 25, 80, 25})
+	for _, id := range tgwIDs {
+		title := fmt.Sprintf("%s %s", tgwNames[id], id)
+		header := func() {
+			pdf.CellFormat(0, rowHeight, title, "1", 1, "C", false, 0, "")
+			writePdfTableHeader(pdf, widths, []string{"Attachment", "Type", "VPC", "State"}, headerHeight)
+		}
+		breakPdfPage(pdf, 26)
+		header()
+		for _, a := range attachments[id] {
+			v := vpcs[a]
+			writePdfMultiLineRow(pdf, widths, lineHeight, []string{
+				fmt.Sprintf("%s\n%s", a.TagName, a.ID),
+				a.ResourceType,
+				fmt.Sprintf("%s %s\n%s", v.TagName, v.ID, v.Region),
+				a.State,
+			}, header)
+		}
+	}
+}
+
+//convertPeeringConnectionsToPdf lists the peering connections of the vpcs on a new page. Those not active are written in red.
+func (nt *Network) convertPeeringConnectionsToPdf(pdf *gofpdf.Fpdf, toc *pdfToc) {
+	rowHeight, headerHeight, lineHeight := nt.rowHeight(), nt.headerHeight(), nt.lineHeight()
+	pcxs := make([]*PeeringConnection, 0)
+	seen := make(map[string]bool)
+	vpcNames := make(map[string]string)
+	for _, v := range nt.Vpcs {
+		vpcNames[v.ID] = v.TagName
+		for _, pcx := range v.PeeringConnections {
+			if !seen[pcx.ID] {
+				seen[pcx.ID] = true
+				pcxs = append(pcxs, pcx)
 			}
 		}
-		rt.AssociationSubnets = asSubnets
-		rts = append(rts, rt)
 	}
-	return rts
+	if len(pcxs) == 0 {
+		return
+	}
+	sort.Slice(pcxs, func(i, j int) bool {
+		return pcxs[i].ID < pcxs[j].ID
+	})
+	pdf.AddPage()
+	if toc != nil {
+		toc.mark("VPC Peering Connections")
+	}
+	pdf.CellFormat(0, rowHeight, "VPC Peering Connections", "", 1, "L", false, 0, "")
+	widths := scalePdfWidths(pdf, []float64{45, 60, 60, 25})
+	header := func() {
+		writePdfTableHeader(pdf, widths, []string{"Connection", "Requester", "Accepter", "Status"}, headerHeight)
+	}
+	header()
+	peer := func(pv *svc.PeeringVpc) string {
+		return strings.TrimSpace(fmt.Sprintf("%s %s\n%s\n%s %s", vpcNames[pv.VpcID], pv.VpcID, pv.CidrBlock, pv.OwnerID, pv.Region))
+	}
+	for _, pcx := range pcxs {
+		if !pcx.IsActive() {
+			pdf.SetTextColor(220, 0, 0)
+		}
+		writePdfMultiLineRow(pdf, widths, lineHeight, []string{
+			strings.TrimSpace(fmt.Sprintf("%s\n%s", pcx.TagName, pcx.ID)),
+			peer(pcx.Requester),
+			peer(pcx.Accepter),
+			pcx.Status,
+		}, header)
+		pdf.SetTextColor(0, 0, 0)
+	}
+}
+
+//convertEipsToPdf lists the elastic ips on a new page. Those unassociated are written in red as they cost without use.
+func (nt *Network) convertEipsToPdf(pdf *gofpdf.Fpdf, toc *pdfToc) {
+	rowHeight, headerHeight, lineHeight := nt.rowHeight(), nt.headerHeight(), nt.lineHeight()
+	if len(nt.Eips) == 0 {
+		return
+	}
+	pdf.AddPage()
+	if toc != nil {
+		toc.mark("Elastic IPs")
+	}
+	unassociated := 0
+	for _, eip := range nt.Eips {
+		if !eip.IsAssociated() {
+			unassociated++
+		}
+	}
+	pdf.CellFormat(0, rowHeight, fmt.Sprintf("Elastic IPs  (%d unassociated)", unassociated), "", 1, "L", false, 0, "")
+	widths := scalePdfWidths(pdf, []float64{35, 50, 30, 25, 50})
+	header := func() {
+		writePdfTableHeader(pdf, widths, []string{"Public IP", "Allocation", "Region", "Type", "Associated With"}, headerHeight)
+	}
+	header()
+	for _, eip := range nt.Eips {
+		if !eip.IsAssociated() {
+			pdf.SetTextColor(220, 0, 0)
+		}
+		writePdfMultiLineRow(pdf, widths, lineHeight, []string{
+			eip.PublicIP,
+			strings.TrimSpace(fmt.Sprintf("%s\n%s", eip.TagName, eip.AllocationID)),
+			eip.Region,
+			eip.AssociationType,
+			strings.TrimSpace(fmt.Sprintf("%s\n%s", eip.AssociatedWith, eip.PrivateIP)),
+		}, header)
+		pdf.SetTextColor(0, 0, 0)
+	}
+}
+
+func (nt *Network) convertNetworkAclToPdf(pdf *gofpdf.Fpdf, vpc *Vpc, acl *NetworkAcl) {
+	rowHeight := nt.rowHeight()
+	sns := make([]string, 0)
+	for _, sn := range vpc.Subnets {
+		if sn.AssociatedNetworkAcl == acl {
+			sns = append(sns, sn.TagName)
+		}
+	}
+	title := fmt.Sprintf("Network ACL: %s %s", acl.TagName, acl.ID)
+	if acl.IsDefault {
+		title += " (default)"
+	}
+	widths := scalePdfWidths(pdf, []float64{20, 25, 25, 35, 55, 30})
+	aclHeader := func() {
+		pdf.CellFormat(0, rowHeight, title, "1", 1, "C", false, 0, "")
+		writePdfTableHeader(pdf, widths, []string{"Rule", "Direction", "Protocol", "Port", "CIDR", "Action"}, rowHeight)
+	}
+	breakPdfPage(pdf, 3*rowHeight)
+	aclHeader()
+	pdf.CellFormat(0, rowHeight, fmt.Sprintf("Subnets: %s", strings.Join(sns, ", ")), "1", 1, "L", false, 0, "")
+	for _, e := range acl.Entries {
+		if breakPdfPage(pdf, rowHeight) {
+			aclHeader()
+		}
+		fill := false
+		switch {
+		case e.IsBroadAllow():
+			pdf.SetFillColor(255, 150, 150)
+			fill = true
+		case e.IsDefaultDeny():
+			pdf.SetFillColor(220, 220, 220)
+			fill = true
+		}
+		rule := fmt.Sprintf("%d", e.RuleNumber)
+		if e.RuleNumber == 32767 {
+			rule = "*"
+		}
+		direction := "inbound"
+		if e.Egress {
+			direction = "outbound"
+		}
+		row := []string{rule, direction, e.ProtocolName(), e.PortRange(), e.CidrBlock, e.RuleAction}
+		for i, col := range row {
+			pdf.CellFormat(widths[i], rowHeight, col, "1", 0, "C", fill, 0, "")
+		}
+		pdf.Ln(-1)
+	}
 }
 
-func parseDescribeSubnetsOutputToSubnets(output *ec2.DescribeSubnetsOutput) []*Subnet {
-	subnets := make([]*Subnet, 0)
-	for _, v := range output.Subnets {
-		sn := &Subnet{
-			ID:        *v.SubnetId,
-			TagName:   extractTagName(v.Tags),
-			CidrBlock: *v.CidrBlock,
+func (nt *Network) convertJSON() {
+	report := svc.NewReport(nt.manager.Region, nt.account, nt.Vpcs)
+	report.Eips = nt.Eips
+	report.SetErrors(nt.Errs)
+	if nt.mask != nil {
+		for i, e := range report.Errors {
+			report.Errors[i] = nt.mask(e)
 		}
-		subnets = append(subnets, sn)
 	}
-	return subnets
+	var b []byte
+	var err error
+	if nt.jsonCompact {
+		b, err = json.Marshal(report)
+	} else {
+		b, err = json.MarshalIndent(report, "", "  ")
+	}
+	if err != nil {
+		nt.stackError(err)
+		return
+	}
+	nt.writeReport(nt.outputPath("network.json"), func(w io.Writer) error {
+		_, err := w.Write(b)
+		return err
+	})
+}
+
+func (nt *Network) outputPath(name string) string {
+	return regionOutputPath(name, nt.regionSuffix)
+}
+
+//regionOutputPath appends the regions to the file name when --output-prefix is given with --all-regions or --regions
+func regionOutputPath(name, regionSuffix string) string {
+	if OutputPrefix != "" && regionSuffix != "" {
+		ext := filepath.Ext(name)
+		name = fmt.Sprintf("%s-%s%s", strings.TrimSuffix(name, ext), regionSuffix, ext)
+	}
+	return outputPath(name)
+}
+
+//writeReport writes the report to path, or to stdout when the output is -
+func (nt *Network) writeReport(path string, write func(w io.Writer) error) {
+	if nt.output == stdoutOutput {
+		if err := write(os.Stdout); err != nil {
+			nt.stackError(err)
+		}
+		return
+	}
+	//the report is written to a temporary file in the same dir and renamed so that path is never left half written
+	f, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		nt.stackError(err)
+		return
+	}
+	err = write(f)
+	if err == nil {
+		err = f.Chmod(0644)
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		nt.stackError(err)
+		return
+	}
+	nt.written = append(nt.written, path)
+}
+
+//uploadS3 uploads the written reports under the prefix with the generation time in their keys
+func (nt *Network) uploadS3(dest string) {
+	bucket, prefix, _, err := parseS3URL(dest)
+	if err != nil {
+		nt.stackError(err)
+		return
+	}
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	timestamp := time.Now().UTC().Format("20060102T150405Z")
+	for _, path := range nt.written {
+		f, err := os.Open(path)
+		if err != nil {
+			nt.stackError(err)
+			continue
+		}
+		ext := filepath.Ext(path)
+		key := fmt.Sprintf("%s%s-%s%s", prefix, strings.TrimSuffix(filepath.Base(path), ext), timestamp, ext)
+		location, err := nt.manager.UploadObject(bucket, key, contentType(ext), f)
+		f.Close()
+		if err != nil {
+			nt.stackError(fmt.Errorf("failed to upload %s: %w", path, err))
+			continue
+		}
+		util.PrintlnGreen(location)
+	}
+}
+
+//stackError does not log err. the errors are printed once by the caller of run.
+func (nt *Network) stackError(err error) *Network {
+	nt.mu.Lock()
+	defer nt.mu.Unlock()
+	nt.Errs = append(nt.Errs, err)
+	return nt
+}
+
+func (nt *Network) flattenErrs() error {
+	return svc.NewAggregateError(nt.Errs)
 }
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+)
+
+func (nt *Network) convertCsv() {
+	nt.writeReport(nt.outputPath("subnets.csv"), nt.writeCsv)
+}
+
+func (nt *Network) writeCsv(f io.Writer) error {
+	w := csv.NewWriter(f)
+	header := []string{"account", "vpc_id", "vpc_name", "subnet_id", "subnet_name", "cidr", "az", "available_ips", "utilization", "route_table"}
+	if nt.options.IncludeInstanceCounts {
+		header = append(header, "instances")
+	}
+	for _, k := range nt.options.ShowTags {
+		header = append(header, "tag:"+k)
+	}
+	w.Write(header)
+	for _, v := range nt.Vpcs {
+		for _, sn := range v.Subnets {
+			var rtID string
+			if sn.AssociatedRouteTable != nil {
+				rtID = sn.AssociatedRouteTable.ID
+			}
+			//left empty when the api did not return the available ips
+			var available, utilization string
+			if sn.AvailableIpAddressCount != nil {
+				available = strconv.FormatInt(*sn.AvailableIpAddressCount, 10)
+				utilization = strconv.FormatFloat(sn.Utilization(), 'f', 2, 64)
+			}
+			row := []string{
+				v.Account,
+				v.ID,
+				v.TagName,
+				sn.ID,
+				sn.TagName,
+				sn.CidrBlock,
+				sn.AvailabilityZone,
+				available,
+				utilization,
+				rtID,
+			}
+			if nt.options.IncludeInstanceCounts {
+				var count string
+				if sn.InstanceCount != nil {
+					count = strconv.Itoa(*sn.InstanceCount)
+				}
+				row = append(row, count)
+			}
+			for _, k := range nt.options.ShowTags {
+				row = append(row, sn.Tags[k])
+			}
+			w.Write(row)
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
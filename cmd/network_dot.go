@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+)
+
+func (nt *Network) convertDot() {
+	buf := nt.dotSource()
+	nt.writeReport(nt.outputPath("network.dot"), func(w io.Writer) error {
+		_, err := buf.WriteTo(w)
+		return err
+	})
+}
+
+//convertPng renders the dot source with graphviz
+func (nt *Network) convertPng() {
+	//rendered before the file is created so that a failure of dot leaves no empty network.png
+	var png, stderr bytes.Buffer
+	cmd := exec.Command(graphvizCommand, "-Tpng")
+	cmd.Stdin = nt.dotSource()
+	cmd.Stdout = &png
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		nt.stackError(fmt.Errorf("%s -Tpng failed: %s %s", graphvizCommand, err, strings.TrimSpace(stderr.String())))
+		return
+	}
+	nt.writeReport(nt.outputPath("network.png"), func(w io.Writer) error {
+		_, err := png.WriteTo(w)
+		return err
+	})
+}
+
+const graphvizCommand = "dot"
+
+func validateGraphviz() error {
+	if _, err := exec.LookPath(graphvizCommand); err != nil {
+		return fmt.Errorf("--format png requires graphviz, whose %s command is not found in PATH. install graphviz or use --format dot", graphvizCommand)
+	}
+	return nil
+}
+
+func (nt *Network) dotSource() *bytes.Buffer {
+	var buf bytes.Buffer
+	buf.WriteString("digraph network {\n")
+	buf.WriteString("  rankdir=LR;\n")
+	gateways := make(map[string]bool)
+	for _, v := range nt.Vpcs {
+		fmt.Fprintf(&buf, "  subgraph %s {\n", dotQuote("cluster_"+v.ID))
+		fmt.Fprintf(&buf, "    label=%s;\n", dotQuote(fmt.Sprintf("%s\n%s\n%s", v.TagName, v.ID, v.CidrBlock)))
+		for _, sn := range v.Subnets {
+			fmt.Fprintf(&buf, "    %s [shape=box, label=%s];\n", dotQuote(sn.ID), dotQuote(fmt.Sprintf("%s\n%s\n%s", sn.TagName, sn.ID, sn.CidrBlock)))
+		}
+		for _, rt := range v.RouteTables {
+			fmt.Fprintf(&buf, "    %s [shape=note, label=%s];\n", dotQuote(rt.ID), dotQuote(fmt.Sprintf("%s\n%s", rt.TagName, rt.ID)))
+		}
+		buf.WriteString("  }\n")
+		for _, sn := range v.Subnets {
+			if sn.AssociatedRouteTable != nil {
+				fmt.Fprintf(&buf, "  %s -> %s;\n", dotQuote(sn.ID), dotQuote(sn.AssociatedRouteTable.ID))
+			}
+		}
+		for _, rt := range v.RouteTables {
+			for _, r := range rt.Routes {
+				if r.Router == "" {
+					continue
+				}
+				if !gateways[r.Router] {
+					gateways[r.Router] = true
+					fmt.Fprintf(&buf, "  %s [shape=diamond, label=%s];\n", dotQuote(r.Router), dotQuote(r.Target()))
+				}
+				fmt.Fprintf(&buf, "  %s -> %s [label=%s];\n", dotQuote(rt.ID), dotQuote(r.Router), dotQuote(r.Destination()))
+			}
+		}
+	}
+	buf.WriteString("}\n")
+	return &buf
+}
+
+func dotQuote(s string) string {
+	s = strings.Replace(s, `\`, `\\`, -1)
+	s = strings.Replace(s, `"`, `\"`, -1)
+	s = strings.Replace(s, "\n", `\n`, -1)
+	return `"` + s + `"`
+}
@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+const networkHTMLTemplate = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Network Report</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+details { margin-bottom: 1em; border: 1px solid #ccc; border-radius: 4px; padding: 0.5em 1em; }
+summary { font-weight: bold; cursor: pointer; }
+table { border-collapse: collapse; margin: 0.5em 0 1em; }
+th, td { border: 1px solid #ccc; padding: 0.2em 0.6em; text-align: left; }
+th { background: #f0f0f0; }
+.full { color: #d00; font-weight: bold; }
+.on { color: #080; font-weight: bold; }
+.off { color: #d00; font-weight: bold; }
+.unknown { color: #888; font-weight: bold; }
+</style>
+</head>
+<body>
+<h1>Network Report</h1>
+<p>Generated at {{.GeneratedAt}}</p>
+{{range .Vpcs}}
+<details>
+<summary>{{.Region}} {{.TagName}} {{.ID}} {{.CidrBlock}}</summary>
+<p class="{{flowLogsClass .}}">{{flowLogsLabel .}}</p>
+{{with .DhcpOptions}}<p>DHCP {{.ID}} domain: {{.DomainName}} dns: {{join .DomainNameServers ", "}} ntp: {{join .NtpServers ", "}}</p>{{end}}
+{{range .RouteTables}}
+<h3>Route Table: {{.TagName}} {{.ID}}{{if .IsMain}} (main){{end}}</h3>
+<table>
+<tr><th>Destination</th><th>Target</th></tr>
+{{range .Routes}}<tr><td>{{.Destination}}</td><td>{{if .RouterName}}{{.RouterName}}{{else}}{{.Router}}{{end}}</td></tr>
+{{end}}</table>
+{{end}}
+<h3>Subnets</h3>
+<table>
+<tr><th>Name</th><th>ID</th><th>CIDR</th><th>AZ</th><th>Utilization</th><th>Route Table</th></tr>
+{{range .Subnets}}<tr><td>{{.TagName}}</td><td>{{.ID}}</td><td>{{.CidrBlock}}</td><td>{{.AvailabilityZone}}</td><td{{if .IsNearlyFull}} class="full"{{end}}>{{.UtilizationLabel}}</td><td>{{with .AssociatedRouteTable}}{{.TagName}} {{.ID}}{{else}}-{{end}}</td></tr>
+{{end}}</table>
+{{range .Subnets}}{{if .Enis}}
+<h3>Network Interfaces: {{.TagName}} {{.ID}}</h3>
+<table>
+<tr><th>Interface</th><th>Private IP</th><th>Type</th><th>Security Groups</th><th>Description</th></tr>
+{{range .Enis}}<tr><td>{{.TagName}} {{.ID}}</td><td>{{join .PrivateIPs ", "}}</td><td>{{.AttachmentType}}</td><td>{{join .SecurityGroups ", "}}</td><td>{{.Description}}</td></tr>
+{{end}}</table>
+{{end}}{{end}}</details>
+{{end}}
+</body>
+</html>
+`
+
+//reportData is the data passed to templates of the network report
+type reportData struct {
+	GeneratedAt string
+	Version     string
+	Account     string
+	Region      string
+	Vpcs        []*Vpc
+}
+
+//reportTemplate is satisfied by both html/template and text/template
+type reportTemplate interface {
+	Execute(w io.Writer, data interface{}) error
+}
+
+var reportTemplateFuncs = map[string]interface{}{
+	"join":          strings.Join,
+	"mdEscape":      mdEscape,
+	"flowLogsLabel": flowLogsLabel,
+	"flowLogsClass": flowLogsClass,
+}
+
+//parseReportTemplate parses the file with html/template for html and text/template for md
+func parseReportTemplate(path, format string) (reportTemplate, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	name := filepath.Base(path)
+	switch format {
+	case "html":
+		tmpl, err := template.New(name).Funcs(reportTemplateFuncs).Parse(string(b))
+		if err != nil {
+			return nil, err
+		}
+		return tmpl, nil
+	case "md":
+		tmpl, err := texttemplate.New(name).Funcs(reportTemplateFuncs).Parse(string(b))
+		if err != nil {
+			return nil, err
+		}
+		return tmpl, nil
+	}
+	return nil, fmt.Errorf("--template is supported only with --format html or md")
+}
+
+func (nt *Network) reportData() *reportData {
+	return &reportData{
+		GeneratedAt: time.Now().UTC().Format(time.RFC3339),
+		Version:     Version,
+		Account:     nt.account,
+		Region:      nt.manager.Region,
+		Vpcs:        nt.Vpcs,
+	}
+}
+
+//executeTemplate writes the report with the template given by --template
+func (nt *Network) executeTemplate(path string) {
+	data := nt.reportData()
+	nt.writeReport(path, func(w io.Writer) error {
+		return nt.template.Execute(w, data)
+	})
+}
+
+func (nt *Network) convertHTML() {
+	if nt.template != nil {
+		nt.executeTemplate(nt.outputPath("network.html"))
+		return
+	}
+	tmpl, err := template.New("network").Funcs(reportTemplateFuncs).Parse(networkHTMLTemplate)
+	if err != nil {
+		nt.stackError(err)
+		return
+	}
+	data := nt.reportData()
+	nt.writeReport(nt.outputPath("network.html"), func(w io.Writer) error {
+		return tmpl.Execute(w, data)
+	})
+}
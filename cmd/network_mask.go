@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"reflect"
+	"regexp"
+)
+
+const maskedAccountID = "XXXXXXXXXXXX"
+
+var (
+	accountIDPattern  = regexp.MustCompile(`\b\d{12}\b`)
+	ipv4Pattern       = regexp.MustCompile(`\b(\d{1,3}\.\d{1,3}\.\d{1,3}\.)\d{1,3}\b`)
+	instanceIDPattern = regexp.MustCompile(`\bi-[0-9a-f]{8,17}\b`)
+)
+
+//maskAccountID replaces account ids, including those in arns, with XXXXXXXXXXXX
+func maskAccountID(s string) string {
+	return accountIDPattern.ReplaceAllString(s, maskedAccountID)
+}
+
+//redact masks account ids, instance ids and the last octet of ipv4 addresses and cidrs except 0.0.0.0/0
+func redact(s string) string {
+	s = maskAccountID(s)
+	s = instanceIDPattern.ReplaceAllString(s, "i-xxxxxxxx")
+	return ipv4Pattern.ReplaceAllStringFunc(s, func(ip string) string {
+		if ip == "0.0.0.0" {
+			return ip
+		}
+		return ipv4Pattern.ReplaceAllString(ip, "${1}x")
+	})
+}
+
+//maskReport applies mask to the account label and every string in the vpcs
+func (nt *Network) maskReport(mask func(string) string) {
+	nt.mask = mask
+	nt.account = mask(nt.account)
+	seen := make(map[uintptr]bool)
+	maskStrings(reflect.ValueOf(nt.Vpcs), mask, seen)
+	maskStrings(reflect.ValueOf(nt.Eips), mask, seen)
+}
+
+//maskStrings applies mask to the exported string fields reachable from v. Each pointer is visited once
+//since subnets point to the route tables and network acls which are also in the vpc.
+func maskStrings(v reflect.Value, mask func(string) string, seen map[uintptr]bool) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() || seen[v.Pointer()] {
+			return
+		}
+		seen[v.Pointer()] = true
+		maskStrings(v.Elem(), mask, seen)
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).PkgPath == "" {
+				maskStrings(v.Field(i), mask, seen)
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			maskStrings(v.Index(i), mask, seen)
+		}
+	case reflect.Map:
+		//map values are not settable, so each is masked in a copy and written back
+		for _, k := range v.MapKeys() {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			elem.Set(v.MapIndex(k))
+			maskStrings(elem, mask, seen)
+			v.SetMapIndex(k, elem)
+		}
+	case reflect.String:
+		if v.CanSet() {
+			v.SetString(mask(v.String()))
+		}
+	}
+}
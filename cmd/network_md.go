@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+func (nt *Network) convertMarkdown() {
+	if nt.template != nil {
+		nt.executeTemplate(nt.outputPath("network.md"))
+		return
+	}
+	var buf bytes.Buffer
+	buf.WriteString("# Network\n")
+	for _, v := range nt.Vpcs {
+		fmt.Fprintf(&buf, "\n## %s %s\n\n", mdEscape(v.TagName), v.ID)
+		fmt.Fprintf(&buf, "- Region: %s\n", v.Region)
+		fmt.Fprintf(&buf, "- CIDR: %s\n", strings.Join(append([]string{v.CidrBlock}, v.Ipv6CidrBlocks...), ", "))
+		fmt.Fprintf(&buf, "- %s\n", mdEscape(flowLogsLabel(v)))
+		if v.DhcpOptions != nil {
+			fmt.Fprintf(&buf, "- %s\n", mdEscape(dhcpOptionsLabel(v.DhcpOptions)))
+		}
+		buf.WriteString("\n### Route Tables\n\n")
+		buf.WriteString("| Route Table | Destination | Target |\n")
+		buf.WriteString("| --- | --- | --- |\n")
+		for _, rt := range v.RouteTables {
+			name := fmt.Sprintf("%s %s", mdEscape(routeTableTitle(rt)), rt.ID)
+			if len(rt.Routes) == 0 {
+				fmt.Fprintf(&buf, "| %s | | |\n", name)
+			}
+			for i, r := range rt.Routes {
+				if i > 0 {
+					name = ""
+				}
+				fmt.Fprintf(&buf, "| %s | %s | %s |\n", name, mdEscape(r.Destination()), mdEscape(r.Target()))
+			}
+		}
+		buf.WriteString("\n### Subnets\n\n")
+		buf.WriteString("| Subnet | CIDR | AZ | Available IPs | Utilization | Route Table |\n")
+		buf.WriteString("| --- | --- | --- | --- | --- | --- |\n")
+		for _, sn := range v.Subnets {
+			rtName := "-"
+			if sn.AssociatedRouteTable != nil {
+				rtName = fmt.Sprintf("%s %s", mdEscape(sn.AssociatedRouteTable.TagName), sn.AssociatedRouteTable.ID)
+			}
+			utilization := sn.UtilizationLabel()
+			if sn.IsNearlyFull() {
+				utilization = "**" + utilization + "**"
+			}
+			cidr := strings.Join(append([]string{sn.CidrBlock}, sn.Ipv6CidrBlocks...), "<br>")
+			fmt.Fprintf(&buf, "| %s %s | %s | %s | %s | %s | %s |\n", mdEscape(sn.TagName), sn.ID, cidr, sn.AvailabilityZone, sn.AvailableIPsLabel(), utilization, rtName)
+		}
+		for _, sn := range v.Subnets {
+			if len(sn.Enis) == 0 {
+				continue
+			}
+			fmt.Fprintf(&buf, "\n### Network Interfaces: %s %s\n\n", mdEscape(sn.TagName), sn.ID)
+			buf.WriteString("| Interface | Private IP | Type | Security Groups | Description |\n")
+			buf.WriteString("| --- | --- | --- | --- | --- |\n")
+			for _, eni := range sn.Enis {
+				fmt.Fprintf(&buf, "| %s %s | %s | %s | %s | %s |\n", mdEscape(eni.TagName), eni.ID, strings.Join(eni.PrivateIPs, "<br>"), eni.AttachmentType, strings.Join(eni.SecurityGroups, "<br>"), mdEscape(eni.Description))
+			}
+		}
+	}
+	nt.writeReport(nt.outputPath("network.md"), func(w io.Writer) error {
+		_, err := buf.WriteTo(w)
+		return err
+	})
+}
+
+//mdEscape escapes characters which break markdown tables
+func mdEscape(s string) string {
+	s = strings.Replace(s, `\`, `\\`, -1)
+	s = strings.Replace(s, "|", `\|`, -1)
+	s = strings.Replace(s, "\n", " ", -1)
+	return s
+}
@@ -1,29 +1,29 @@
 package cmd
 
-type Vpc struct {
-	ID                   string
-	TagName              string
-	CidrBlock            string
-	AssociatedCidrBlocks []string
-	RouteTables          []*RouteTable
-	Subnets              []*Subnet
-}
-
-type RouteTable struct {
-	ID                 string
-	TagName            string
-	Routes             []*Route
-	AssociationSubnets []string //subnet-id
-}
-
-type Route struct {
-	DestinationCidrBlock string
-	Router               string
-}
-
-type Subnet struct {
-	ID                   string
-	TagName              string
-	CidrBlock            string
-	AssociatedRouteTable *RouteTable
-}
+import "github.com/atsushi-ishibashi/aws-state-report/svc"
+
+type Vpc = svc.Vpc
+
+type RouteTable = svc.RouteTable
+
+type Route = svc.Route
+
+type Subnet = svc.Subnet
+
+type NetworkAcl = svc.NetworkAcl
+
+type NetworkAclEntry = svc.NetworkAclEntry
+
+type VpcEndpoint = svc.VpcEndpoint
+
+type TransitGatewayAttachment = svc.TransitGatewayAttachment
+
+type Eni = svc.Eni
+
+type DhcpOptions = svc.DhcpOptions
+
+type FlowLog = svc.FlowLog
+
+type PeeringConnection = svc.PeeringConnection
+
+type Eip = svc.Eip
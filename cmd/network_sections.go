@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+)
+
+//pdfSections are the sections of the network pdf which --only and --skip pick
+var pdfSections = []string{
+	"summary",
+	"route-tables",
+	"subnets",
+	"endpoints",
+	"network-interfaces",
+	"nacls",
+	"flow-logs",
+	"dhcp",
+	"transit-gateways",
+	"peering",
+	"eips",
+}
+
+type pdfSectionSet map[string]bool
+
+//parsePdfSections returns the sections in only minus those in skip, or nil when neither is given
+func parsePdfSections(only, skip string) (pdfSectionSet, error) {
+	if only == "" && skip == "" {
+		return nil, nil
+	}
+	sections := make(pdfSectionSet)
+	if only == "" {
+		for _, s := range pdfSections {
+			sections[s] = true
+		}
+	}
+	for _, flag := range []struct {
+		name  string
+		value string
+		on    bool
+	}{
+		{"--only", only, true},
+		{"--skip", skip, false},
+	} {
+		for _, s := range strings.Split(flag.value, ",") {
+			s = strings.TrimSpace(s)
+			if s == "" {
+				continue
+			}
+			if !isPdfSection(s) {
+				return nil, fmt.Errorf("unknown section %s in %s. %s", s, flag.name, strings.Join(pdfSections, ", "))
+			}
+			sections[s] = flag.on
+		}
+	}
+	return sections, nil
+}
+
+func isPdfSection(s string) bool {
+	for _, v := range pdfSections {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+//renders reports whether the section is rendered. A nil set renders all.
+func (ss pdfSectionSet) renders(section string) bool {
+	return ss == nil || ss[section]
+}
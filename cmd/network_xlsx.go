@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/tealeg/xlsx"
+)
+
+//addInventorySheets adds vpcs, subnets and route tables sheets with one row per resource joined by vpc id
+func (nt *Network) addInventorySheets(file *xlsx.File) {
+	vpcRows := make([][]string, 0)
+	snRows := make([][]string, 0)
+	rtRows := make([][]string, 0)
+	eniRows := make([][]string, 0)
+	for _, v := range nt.Vpcs {
+		vpcRows = append(vpcRows, []string{
+			v.ID,
+			v.Region,
+			v.TagName,
+			v.CidrBlock,
+			strings.Join(v.Ipv6CidrBlocks, ", "),
+			strconv.FormatBool(v.IsDefault),
+			flowLogsLabel(v),
+		})
+		for _, sn := range v.Subnets {
+			var rtID, aclID string
+			if sn.AssociatedRouteTable != nil {
+				rtID = sn.AssociatedRouteTable.ID
+			}
+			if sn.AssociatedNetworkAcl != nil {
+				aclID = sn.AssociatedNetworkAcl.ID
+			}
+			snRows = append(snRows, []string{
+				v.ID,
+				sn.ID,
+				sn.TagName,
+				sn.CidrBlock,
+				strings.Join(sn.Ipv6CidrBlocks, ", "),
+				sn.AvailabilityZone,
+				sn.AvailableIPsLabel(),
+				sn.UtilizationLabel(),
+				rtID,
+				aclID,
+			})
+			for _, eni := range sn.Enis {
+				eniRows = append(eniRows, []string{
+					v.ID,
+					sn.ID,
+					eni.ID,
+					eni.TagName,
+					strings.Join(eni.PrivateIPs, ", "),
+					eni.AttachmentType,
+					strings.Join(eni.SecurityGroups, ", "),
+					eni.Description,
+				})
+			}
+		}
+		for _, rt := range v.RouteTables {
+			routes := make([]string, 0, len(rt.Routes))
+			for _, r := range rt.Routes {
+				routes = append(routes, fmt.Sprintf("%s -> %s", r.Destination(), r.Target()))
+			}
+			rtRows = append(rtRows, []string{
+				v.ID,
+				rt.ID,
+				rt.TagName,
+				strconv.FormatBool(rt.IsMain),
+				strings.Join(routes, ", "),
+				strings.Join(rt.AssociationSubnets, ", "),
+			})
+		}
+	}
+	nt.addInventorySheet(file, "vpcs", []string{"VPC ID", "Region", "Name", "CIDR", "IPv6 CIDR", "Default", "Flow Logs"}, vpcRows)
+	nt.addInventorySheet(file, "subnets", []string{"VPC ID", "Subnet ID", "Name", "CIDR", "IPv6 CIDR", "AZ", "Available IPs", "Utilization", "Route Table ID", "Network ACL ID"}, snRows)
+	nt.addInventorySheet(file, "route_tables", []string{"VPC ID", "Route Table ID", "Name", "Main", "Routes", "Association Subnets"}, rtRows)
+	if len(eniRows) > 0 {
+		nt.addInventorySheet(file, "network_interfaces", []string{"VPC ID", "Subnet ID", "Interface ID", "Name", "Private IPs", "Type", "Security Groups", "Description"}, eniRows)
+	}
+}
+
+//maxSheetName is the max number of characters of a sheet name in excel
+const maxSheetName = 31
+
+//vpcSheetName names the sheet of the vpc by its tag name or id with the characters excel forbids replaced.
+//A number is appended when an inventory sheet or another vpc has taken the name.
+func vpcSheetName(file *xlsx.File, v *Vpc) string {
+	base := v.TagName
+	if base == "" {
+		base = v.ID
+	}
+	base = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`:\/?*[]`, r) {
+			return '_'
+		}
+		return r
+	}, base)
+	name := truncateText(base, maxSheetName)
+	for i := 2; sheetNameTaken(file, name); i++ {
+		suffix := fmt.Sprintf(" (%d)", i)
+		name = truncateText(base, maxSheetName-len(suffix)) + suffix
+	}
+	return name
+}
+
+//sheetNameTaken compares the names case-insensitively as excel does
+func sheetNameTaken(file *xlsx.File, name string) bool {
+	for taken := range file.Sheet {
+		if strings.EqualFold(taken, name) {
+			return true
+		}
+	}
+	return false
+}
+
+//addInventorySheet writes a header row frozen at the top and sizes the columns to their longest value
+func (nt *Network) addInventorySheet(file *xlsx.File, name string, header []string, rows [][]string) {
+	sheet, err := file.AddSheet(name)
+	if err != nil {
+		nt.stackError(err)
+		return
+	}
+	widths := make([]int, len(header))
+	for i, h := range header {
+		cell := sheet.Cell(0, i)
+		cell.Value = h
+		cell.SetStyle(borderWithAlign("lrtb", true))
+		widths[i] = len(h)
+	}
+	for r, row := range rows {
+		for i, col := range row {
+			sheet.Cell(r+1, i).Value = col
+			if len(col) > widths[i] {
+				widths[i] = len(col)
+			}
+		}
+	}
+	for i, w := range widths {
+		if w > 80 {
+			w = 80
+		}
+		sheet.SetColWidth(i, i, float64(w+2))
+	}
+	sheet.SheetViews = []xlsx.SheetView{
+		{Pane: &xlsx.Pane{YSplit: 1, TopLeftCell: "A2", ActivePane: "bottomLeft", State: "frozen"}},
+	}
+}
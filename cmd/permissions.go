@@ -0,0 +1,179 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/atsushi-ishibashi/aws-state-report/util"
+	"github.com/urfave/cli"
+)
+
+//networkActions are the actions svc.BuildNetworkModel calls
+var networkActions = []string{
+	"ec2:DescribeVpcs",
+	"ec2:DescribeRouteTables",
+	"ec2:DescribeSubnets",
+	"ec2:DescribeNetworkAcls",
+	"ec2:DescribeInternetGateways",
+	"ec2:DescribeNatGateways",
+	"ec2:DescribeVpnGateways",
+	"ec2:DescribeVpcPeeringConnections",
+	"ec2:DescribeVpcEndpoints",
+	"ec2:DescribeTransitGateways",
+	"ec2:DescribeTransitGatewayAttachments",
+	"ec2:DescribeManagedPrefixLists",
+	"ec2:GetManagedPrefixListEntries",
+	"ec2:DescribeNetworkInterfaces",
+	"ec2:DescribeDhcpOptions",
+	"ec2:DescribeFlowLogs",
+	"ec2:DescribeAddresses",
+	"ec2:DescribeInstances",
+}
+
+//commandActions is the registry of the actions each command calls.
+//Add the actions here when a command starts calling a new api.
+var commandActions = map[string][]string{
+	"network": append([]string{
+		"ec2:DescribeRegions",
+		"sts:GetCallerIdentity",
+		"iam:ListAccountAliases",
+		"s3:PutObject",
+	}, networkActions...),
+	"iam": {
+		"iam:ListPolicies",
+		"iam:GetPolicyVersion",
+		"iam:ListGroups",
+		"iam:ListGroupPolicies",
+		"iam:ListAttachedGroupPolicies",
+		"iam:ListUsers",
+		"iam:ListUserPolicies",
+		"iam:ListAttachedUserPolicies",
+		"iam:ListGroupsForUser",
+		"iam:ListRoles",
+		"iam:ListRolePolicies",
+		"iam:ListAttachedRolePolicies",
+	},
+	"sg": {
+		"ec2:DescribeVpcs",
+		"ec2:DescribeSecurityGroups",
+		"ec2:DescribeNetworkInterfaces",
+		"ec2:DescribeInstances",
+	},
+	"ec2": {
+		"ec2:DescribeInstances",
+	},
+	"route53": {
+		"route53:ListHostedZones",
+		"route53:ListResourceRecordSets",
+	},
+	"elb": {
+		"elasticloadbalancing:DescribeLoadBalancers",
+	},
+	"topology": {
+		"ec2:DescribeInstances",
+		"ec2:DescribeSecurityGroups",
+		"ec2:DescribeSubnets",
+		"ec2:DescribeNetworkInterfaces",
+	},
+	"rds": {
+		"rds:DescribeDBInstances",
+	},
+	"s3": {
+		"s3:ListAllMyBuckets",
+		"s3:GetBucketLocation",
+		"s3:GetBucketPublicAccessBlock",
+		"s3:GetBucketPolicyStatus",
+		"s3:GetBucketAcl",
+		"s3:GetEncryptionConfiguration",
+	},
+	"ecs": append([]string{
+		"ecs:ListClusters",
+		"ecs:DescribeClusters",
+		"ecs:ListServices",
+		"ecs:DescribeServices",
+	}, networkActions...),
+	"config": {
+		"sts:GetCallerIdentity",
+	},
+	"drift": append([]string{
+		"s3:GetObject",
+		"s3:GetObjectVersion",
+	}, networkActions...),
+}
+
+type policyDocument struct {
+	Version   string             `json:"Version"`
+	Statement []*policyStatement `json:"Statement"`
+}
+
+type policyStatement struct {
+	Effect   string   `json:"Effect"`
+	Action   []string `json:"Action"`
+	Resource string   `json:"Resource"`
+}
+
+func NewPermissionsCommand() cli.Command {
+	return cli.Command{
+		Name:  "permissions",
+		Usage: "print an iam policy allowing the actions the commands call.",
+		Flags: []cli.Flag{
+			cli.StringSliceFlag{
+				Name:  "command",
+				Usage: "include only the actions of the command. repeatable",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			commands := c.StringSlice("command")
+			if len(commands) == 0 {
+				for name := range commandActions {
+					commands = append(commands, name)
+				}
+			}
+			policy, err := buildPolicyDocument(commands)
+			if err != nil {
+				return util.ErrorRed(err.Error())
+			}
+			b, err := json.MarshalIndent(policy, "", "  ")
+			if err != nil {
+				return util.ErrorRed(err.Error())
+			}
+			fmt.Println(string(b))
+			return nil
+		},
+	}
+}
+
+func buildPolicyDocument(commands []string) (*policyDocument, error) {
+	seen := make(map[string]bool)
+	actions := make([]string, 0)
+	for _, name := range commands {
+		as, ok := commandActions[name]
+		if !ok {
+			known := make([]string, 0, len(commandActions))
+			for k := range commandActions {
+				known = append(known, k)
+			}
+			sort.Strings(known)
+			return nil, fmt.Errorf("unknown command: %s. one of %s", name, strings.Join(known, ", "))
+		}
+		for _, a := range as {
+			if !seen[a] {
+				seen[a] = true
+				actions = append(actions, a)
+			}
+		}
+	}
+	sort.Strings(actions)
+	return &policyDocument{
+		Version: "2012-10-17",
+		Statement: []*policyStatement{
+			&policyStatement{
+				Effect:   "Allow",
+				Action:   actions,
+				Resource: "*",
+			},
+		},
+	}, nil
+}
@@ -0,0 +1,173 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/atsushi-ishibashi/aws-state-report/svc"
+	"github.com/atsushi-ishibashi/aws-state-report/util"
+	"github.com/aws/aws-sdk-go/service/rds"
+	"github.com/jung-kurt/gofpdf"
+	"github.com/urfave/cli"
+)
+
+func NewRDSCommand() cli.Command {
+	return cli.Command{
+		Name:  "rds",
+		Usage: "export rds db instances grouped by vpc in pdf file.",
+		Action: func(c *cli.Context) error {
+			if err := validateFontFile(c.GlobalString("font")); err != nil {
+				return util.ErrorRed(err.Error())
+			}
+			if err := util.ConfigAWS(c); err != nil {
+				return util.ErrorRed(err.Error())
+			}
+			ctx, cancel := withTimeout(c.GlobalDuration("timeout"))
+			defer cancel()
+			mng, err := svc.NewManager(ctx)
+			if err != nil {
+				return util.ErrorRed(err.Error())
+			}
+			r := &RDS{
+				manager:  mng,
+				fontFile: c.GlobalString("font"),
+				Errs:     make([]error, 0),
+			}
+			if err := r.recursiveConstruct(); err != nil {
+				return util.ErrorRed(err.Error())
+			}
+			if c.GlobalBool("dry-run") {
+				return nil
+			}
+			r.convertPdf()
+			if err := r.flattenErrs(); err != nil {
+				return util.ErrorRed(err.Error())
+			}
+			return nil
+		},
+	}
+}
+
+type RDS struct {
+	DBInstances []*DBInstance
+	manager     *svc.Manager
+	fontFile    string
+	Errs        []error
+}
+
+func (r *RDS) recursiveConstruct() error {
+	r.constructDBInstances()
+	return r.flattenErrs()
+}
+
+func (r *RDS) constructDBInstances() *RDS {
+	result, err := r.manager.FetchDBInstances()
+	if err != nil {
+		return r.stackError(err)
+	}
+	r.DBInstances = parseDescribeDBInstancesOutput(result)
+	return r
+}
+
+//convertPdf writes publicly accessible instances in red
+func (r *RDS) convertPdf() {
+	grouped := make(map[string][]*DBInstance)
+	for _, v := range r.DBInstances {
+		grouped[v.VpcID] = append(grouped[v.VpcID], v)
+	}
+	vpcIDs := make([]string, 0, len(grouped))
+	for vpcID := range grouped {
+		vpcIDs = append(vpcIDs, vpcID)
+	}
+	sort.Strings(vpcIDs)
+
+	header := []string{"Identifier", "Engine", "Class", "Multi-AZ", "Subnet Group", "Security Groups"}
+	widths := []float64{45, 25, 30, 15, 35, 40}
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	font := pdfFont(pdf, r.fontFile)
+	setPdfFooter(pdf, font)
+	pdf.AddPage()
+	pdf.SetFont(font, "", 8)
+	pdf.CellFormat(0, 10, fmt.Sprintf("Region: %s", r.manager.Region), "", 1, "L", false, 0, "")
+	printHeader := func() {
+		writePdfTableHeader(pdf, widths, header, 8)
+	}
+	for i, vpcID := range vpcIDs {
+		if i > 0 {
+			pdf.AddPage()
+		}
+		title := vpcID
+		if title == "" {
+			title = "EC2-Classic"
+		}
+		pdf.CellFormat(0, 10, fmt.Sprintf("%s  (%d db instances)", title, len(grouped[vpcID])), "1", 0, "C", false, 0, "")
+		pdf.Ln(-1)
+		printHeader()
+		for _, db := range grouped[vpcID] {
+			identifier := db.Identifier
+			if db.PubliclyAccessible {
+				identifier += "\n(publicly accessible)"
+				pdf.SetTextColor(220, 0, 0)
+			}
+			multiAZ := "no"
+			if db.MultiAZ {
+				multiAZ = "yes"
+			}
+			row := []string{identifier, db.Engine, db.Class, multiAZ, db.SubnetGroup, strings.Join(db.SecurityGroups, "\n")}
+			writePdfMultiLineRow(pdf, widths, 5, row, printHeader)
+			pdf.SetTextColor(0, 0, 0)
+		}
+	}
+	if len(vpcIDs) == 0 {
+		pdf.CellFormat(0, 10, "No DB Instances", "1", 0, "C", false, 0, "")
+	}
+	if err := pdf.OutputFileAndClose(outputPath("rds.pdf")); err != nil {
+		r.stackError(err)
+	}
+}
+
+func (r *RDS) stackError(err error) *RDS {
+	util.LogError(err)
+	r.Errs = append(r.Errs, err)
+	return r
+}
+
+func (r *RDS) flattenErrs() error {
+	return svc.NewAggregateError(r.Errs)
+}
+
+func parseDescribeDBInstancesOutput(output *rds.DescribeDBInstancesOutput) []*DBInstance {
+	dbs := make([]*DBInstance, 0)
+	for _, v := range output.DBInstances {
+		if v.DBInstanceIdentifier == nil {
+			continue
+		}
+		db := &DBInstance{
+			Identifier:     *v.DBInstanceIdentifier,
+			Engine:         stringOrDash(v.Engine),
+			Class:          stringOrDash(v.DBInstanceClass),
+			SubnetGroup:    "-",
+			SecurityGroups: make([]string, 0),
+		}
+		if v.MultiAZ != nil {
+			db.MultiAZ = *v.MultiAZ
+		}
+		if v.PubliclyAccessible != nil {
+			db.PubliclyAccessible = *v.PubliclyAccessible
+		}
+		if v.DBSubnetGroup != nil {
+			db.SubnetGroup = stringOrDash(v.DBSubnetGroup.DBSubnetGroupName)
+			if v.DBSubnetGroup.VpcId != nil {
+				db.VpcID = *v.DBSubnetGroup.VpcId
+			}
+		}
+		for _, g := range v.VpcSecurityGroups {
+			if g.VpcSecurityGroupId != nil {
+				db.SecurityGroups = append(db.SecurityGroups, *g.VpcSecurityGroupId)
+			}
+		}
+		dbs = append(dbs, db)
+	}
+	return dbs
+}
@@ -0,0 +1,12 @@
+package cmd
+
+type DBInstance struct {
+	Identifier         string
+	Engine             string
+	Class              string
+	MultiAZ            bool
+	PubliclyAccessible bool
+	VpcID              string
+	SubnetGroup        string
+	SecurityGroups     []string
+}
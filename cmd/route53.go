@@ -0,0 +1,198 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/atsushi-ishibashi/aws-state-report/svc"
+	"github.com/atsushi-ishibashi/aws-state-report/util"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/jung-kurt/gofpdf"
+	"github.com/urfave/cli"
+)
+
+func NewRoute53Command() cli.Command {
+	return cli.Command{
+		Name:  "route53",
+		Usage: "export route53 hosted zones and record sets in pdf file.",
+		Action: func(c *cli.Context) error {
+			if err := validateFontFile(c.GlobalString("font")); err != nil {
+				return util.ErrorRed(err.Error())
+			}
+			if err := util.ConfigAWS(c); err != nil {
+				return util.ErrorRed(err.Error())
+			}
+			ctx, cancel := withTimeout(c.GlobalDuration("timeout"))
+			defer cancel()
+			mng, err := svc.NewManager(ctx)
+			if err != nil {
+				return util.ErrorRed(err.Error())
+			}
+			r := &Route53{
+				manager:  mng,
+				fontFile: c.GlobalString("font"),
+				Errs:     make([]error, 0),
+			}
+			if err := r.recursiveConstruct(); err != nil {
+				return util.ErrorRed(err.Error())
+			}
+			if c.GlobalBool("dry-run") {
+				return nil
+			}
+			r.convertPdf()
+			if err := r.flattenErrs(); err != nil {
+				return util.ErrorRed(err.Error())
+			}
+			return nil
+		},
+	}
+}
+
+type Route53 struct {
+	HostedZones []*HostedZone
+	manager     *svc.Manager
+	fontFile    string
+	Errs        []error
+}
+
+func (r *Route53) recursiveConstruct() error {
+	r.constructHostedZones().
+		constructRecordSets()
+	return r.flattenErrs()
+}
+
+func (r *Route53) constructHostedZones() *Route53 {
+	result, err := r.manager.FetchHostedZones()
+	if err != nil {
+		return r.stackError(err)
+	}
+	r.HostedZones = parseListHostedZonesOutput(result)
+	return r
+}
+
+func (r *Route53) constructRecordSets() *Route53 {
+	for _, zone := range r.HostedZones {
+		result, err := r.manager.FetchResourceRecordSets(zone.ID)
+		if err != nil {
+			r.stackError(fmt.Errorf("%s: %w", zone.Name, err))
+			continue
+		}
+		zone.RecordSets = parseListResourceRecordSetsOutput(result)
+	}
+	return r
+}
+
+func (r *Route53) convertPdf() {
+	header := []string{"Name", "Type", "TTL", "Value"}
+	widths := []float64{60, 15, 15, 100}
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	font := pdfFont(pdf, r.fontFile)
+	setPdfFooter(pdf, font)
+	pdf.SetFont(font, "", 8)
+	printHeader := func() {
+		writePdfTableHeader(pdf, widths, header, 8)
+	}
+	for _, zone := range r.HostedZones {
+		pdf.AddPage()
+		visibility := "public"
+		if zone.IsPrivate {
+			visibility = "private"
+		}
+		pdf.CellFormat(0, 10, fmt.Sprintf("%s  %s (%s, %d records)", zone.Name, zone.ID, visibility, zone.RecordCount), "1", 0, "C", false, 0, "")
+		pdf.Ln(-1)
+		printHeader()
+		for _, rs := range zone.RecordSets {
+			ttl := "-"
+			if rs.AliasTarget == "" {
+				ttl = strconv.FormatInt(rs.TTL, 10)
+			}
+			writePdfMultiLineRow(pdf, widths, 5, []string{rs.Name, rs.Type, ttl, rs.Value()}, printHeader)
+		}
+		if len(zone.RecordSets) == 0 {
+			pdf.CellFormat(0, 10, "No Record Sets", "1", 0, "C", false, 0, "")
+		}
+	}
+	if len(r.HostedZones) == 0 {
+		pdf.AddPage()
+		pdf.CellFormat(0, 10, "No Hosted Zones", "1", 0, "C", false, 0, "")
+	}
+	if err := pdf.OutputFileAndClose(outputPath("route53.pdf")); err != nil {
+		r.stackError(err)
+	}
+}
+
+func (r *Route53) stackError(err error) *Route53 {
+	util.LogError(err)
+	r.Errs = append(r.Errs, err)
+	return r
+}
+
+func (r *Route53) flattenErrs() error {
+	return svc.NewAggregateError(r.Errs)
+}
+
+func parseListHostedZonesOutput(output *route53.ListHostedZonesOutput) []*HostedZone {
+	zones := make([]*HostedZone, 0)
+	for _, v := range output.HostedZones {
+		if v.Id == nil {
+			continue
+		}
+		zone := &HostedZone{
+			ID:   strings.TrimPrefix(*v.Id, "/hostedzone/"),
+			Name: unescapeRecordName(stringOrDash(v.Name)),
+		}
+		if v.Config != nil && v.Config.PrivateZone != nil {
+			zone.IsPrivate = *v.Config.PrivateZone
+		}
+		if v.ResourceRecordSetCount != nil {
+			zone.RecordCount = *v.ResourceRecordSetCount
+		}
+		zones = append(zones, zone)
+	}
+	return zones
+}
+
+func parseListResourceRecordSetsOutput(output *route53.ListResourceRecordSetsOutput) []*RecordSet {
+	rss := make([]*RecordSet, 0)
+	for _, v := range output.ResourceRecordSets {
+		rs := &RecordSet{
+			Name: unescapeRecordName(stringOrDash(v.Name)),
+			Type: stringOrDash(v.Type),
+		}
+		if v.TTL != nil {
+			rs.TTL = *v.TTL
+		}
+		values := make([]string, 0)
+		for _, rr := range v.ResourceRecords {
+			if rr.Value != nil {
+				values = append(values, *rr.Value)
+			}
+		}
+		rs.Values = values
+		if v.AliasTarget != nil && v.AliasTarget.DNSName != nil {
+			rs.AliasTarget = strings.TrimSuffix(*v.AliasTarget.DNSName, ".")
+		}
+		rss = append(rss, rs)
+	}
+	return rss
+}
+
+//unescapeRecordName restores the characters route53 returns in octal escapes such as \052 for *
+func unescapeRecordName(name string) string {
+	if !strings.Contains(name, `\`) {
+		return name
+	}
+	var b strings.Builder
+	for i := 0; i < len(name); i++ {
+		if name[i] == '\\' && i+3 < len(name) {
+			if c, err := strconv.ParseUint(name[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(c))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(name[i])
+	}
+	return b.String()
+}
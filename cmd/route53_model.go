@@ -0,0 +1,27 @@
+package cmd
+
+import "strings"
+
+type HostedZone struct {
+	ID          string
+	Name        string
+	IsPrivate   bool
+	RecordCount int64
+	RecordSets  []*RecordSet
+}
+
+type RecordSet struct {
+	Name        string
+	Type        string
+	TTL         int64
+	Values      []string
+	AliasTarget string
+}
+
+//Value returns the alias target for alias records and the joined values otherwise
+func (rs *RecordSet) Value() string {
+	if rs.AliasTarget != "" {
+		return "ALIAS " + rs.AliasTarget
+	}
+	return strings.Join(rs.Values, "\n")
+}
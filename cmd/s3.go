@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/atsushi-ishibashi/aws-state-report/svc"
+	"github.com/atsushi-ishibashi/aws-state-report/util"
+	"github.com/jung-kurt/gofpdf"
+	"github.com/urfave/cli"
+)
+
+func NewS3Command() cli.Command {
+	return cli.Command{
+		Name:  "s3",
+		Usage: "export s3 buckets with their public access settings and encryption in pdf file.",
+		Action: func(c *cli.Context) error {
+			if err := validateFontFile(c.GlobalString("font")); err != nil {
+				return util.ErrorRed(err.Error())
+			}
+			if err := util.ConfigAWS(c); err != nil {
+				return util.ErrorRed(err.Error())
+			}
+			ctx, cancel := withTimeout(c.GlobalDuration("timeout"))
+			defer cancel()
+			mng, err := svc.NewManager(ctx)
+			if err != nil {
+				return util.ErrorRed(err.Error())
+			}
+			s := &S3{
+				manager:  mng,
+				fontFile: c.GlobalString("font"),
+				Errs:     make([]error, 0),
+			}
+			//the buckets fetched successfully are written even if others failed
+			s.recursiveConstruct()
+			if c.GlobalBool("dry-run") {
+				return nil
+			}
+			s.convertPdf()
+			if err := s.flattenErrs(); err != nil {
+				return util.ErrorRed(err.Error())
+			}
+			return nil
+		},
+	}
+}
+
+type S3 struct {
+	Buckets  []*Bucket
+	manager  *svc.Manager
+	fontFile string
+	Errs     []error
+}
+
+func (s *S3) recursiveConstruct() error {
+	buckets, errs := s.manager.FetchBuckets()
+	for _, err := range errs {
+		s.stackError(err)
+	}
+	s.Buckets = buckets
+	return s.flattenErrs()
+}
+
+//convertPdf writes publicly accessible buckets in red and notes the buckets whose checks failed
+func (s *S3) convertPdf() {
+	header := []string{"Bucket", "Region", "Bucket-level Block", "Policy / ACL", "Encryption"}
+	widths := []float64{70, 30, 30, 30, 30}
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	font := pdfFont(pdf, s.fontFile)
+	setPdfFooter(pdf, font)
+	pdf.AddPage()
+	pdf.SetFont(font, "", 8)
+	public, incomplete := 0, 0
+	for _, b := range s.Buckets {
+		if b.IsPublic() {
+			public++
+		}
+		if b.IsIncomplete() {
+			incomplete++
+		}
+	}
+	pdf.CellFormat(0, 10, fmt.Sprintf("S3 Buckets  (%d buckets, %d public, %d with failed checks)", len(s.Buckets), public, incomplete), "", 1, "L", false, 0, "")
+	pdf.CellFormat(0, 6, "The public access block of the account is not read. Only that of each bucket is considered.", "", 1, "L", false, 0, "")
+	printHeader := func() {
+		writePdfTableHeader(pdf, widths, header, 8)
+	}
+	printHeader()
+	for _, b := range s.Buckets {
+		name := b.Name
+		if b.IsPublic() {
+			name += "\n(publicly accessible)"
+			pdf.SetTextColor(220, 0, 0)
+		}
+		if b.IsIncomplete() {
+			name += "\n(some checks failed)"
+		}
+		row := []string{name, b.Region, b.PublicAccessBlock, bucketGrants(b), b.Encryption}
+		writePdfMultiLineRow(pdf, widths, 5, row, printHeader)
+		pdf.SetTextColor(0, 0, 0)
+	}
+	if len(s.Buckets) == 0 {
+		pdf.CellFormat(0, 10, "No Buckets", "1", 0, "C", false, 0, "")
+	}
+	if err := pdf.OutputFileAndClose(outputPath("s3.pdf")); err != nil {
+		s.stackError(err)
+	}
+}
+
+//bucketGrants lists policy and acl when they grant public access, with unknown for a failed check
+//and the setting of the public access block which cancels the grant
+func bucketGrants(b *Bucket) string {
+	grants := make([]string, 0, 2)
+	for _, g := range []struct {
+		name     string
+		public   *bool
+		canceled bool
+		by       string
+	}{
+		{"policy", b.PolicyPublic, b.RestrictPublicBuckets, "restricted"},
+		{"acl", b.AclPublic, b.IgnorePublicAcls, "ignored"},
+	} {
+		switch {
+		case g.public == nil:
+			grants = append(grants, g.name+" unknown")
+		case *g.public && g.canceled:
+			grants = append(grants, fmt.Sprintf("%s (%s)", g.name, g.by))
+		case *g.public:
+			grants = append(grants, g.name)
+		}
+	}
+	if len(grants) == 0 {
+		return "private"
+	}
+	return strings.Join(grants, ", ")
+}
+
+func (s *S3) stackError(err error) *S3 {
+	util.LogError(err)
+	s.Errs = append(s.Errs, err)
+	return s
+}
+
+func (s *S3) flattenErrs() error {
+	return svc.NewAggregateError(s.Errs)
+}
@@ -0,0 +1,5 @@
+package cmd
+
+import "github.com/atsushi-ishibashi/aws-state-report/svc"
+
+type Bucket = svc.Bucket
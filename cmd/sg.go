@@ -9,6 +9,7 @@ import (
 	"github.com/atsushi-ishibashi/aws-state-report/util"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/jung-kurt/gofpdf"
 	"github.com/tealeg/xlsx"
 	"github.com/urfave/cli"
 )
@@ -23,12 +24,18 @@ func NewSGCommand() cli.Command {
 				Usage: "file name to export",
 				Value: "sg",
 			},
+			cli.BoolFlag{
+				Name:  "pdf-mode",
+				Usage: "output security groups grouped by vpc in pdf file.",
+			},
 		},
 		Action: func(c *cli.Context) error {
 			if err := util.ConfigAWS(c); err != nil {
 				return util.ErrorRed(err.Error())
 			}
-			mng, err := svc.NewManager()
+			ctx, cancel := withTimeout(c.GlobalDuration("timeout"))
+			defer cancel()
+			mng, err := svc.NewManager(ctx)
 			if err != nil {
 				return util.ErrorRed(err.Error())
 			}
@@ -36,10 +43,32 @@ func NewSGCommand() cli.Command {
 				manager: mng,
 				Errs:    make([]error, 0),
 			}
+			if c.Bool("pdf-mode") {
+				if err := validateFontFile(c.GlobalString("font")); err != nil {
+					return util.ErrorRed(err.Error())
+				}
+				if err := sg.constructByVpc(); err != nil {
+					return util.ErrorRed(err.Error())
+				}
+				if c.GlobalBool("dry-run") {
+					return nil
+				}
+				sg.convertPdf(c.String("src"), c.GlobalString("font"))
+				if err := sg.flattenErrs(); err != nil {
+					return util.ErrorRed(err.Error())
+				}
+				return nil
+			}
 			if err := sg.recursiveConstruct(); err != nil {
 				return util.ErrorRed(err.Error())
 			}
+			if c.GlobalBool("dry-run") {
+				return nil
+			}
 			sg.convertXlsx(c.String("src"))
+			if err := sg.flattenErrs(); err != nil {
+				return util.ErrorRed(err.Error())
+			}
 			return nil
 		},
 	}
@@ -47,16 +76,67 @@ func NewSGCommand() cli.Command {
 
 type SG struct {
 	SecurityGroups []*SecurityGroup
+	Vpcs           []*Vpc
 	manager        *svc.Manager
 	Errs           []error
 }
 
 func (sg *SG) recursiveConstruct() error {
 	sg.constructSecurityGroups().
-		constructNetworkInterfaces()
+		constructNetworkInterfaces().
+		resolveGroupReferences()
+	return sg.flattenErrs()
+}
+
+func (sg *SG) constructByVpc() error {
+	result, err := sg.manager.FetchVpcs()
+	if err != nil {
+		return err
+	}
+	vpcs, warns := svc.ParseDescribeVpcsOutputToVpcs(result)
+	sg.Vpcs = vpcs
+	for _, w := range warns {
+		sg.stackError(w)
+	}
+	sgs := make([]*SecurityGroup, 0)
+	for _, vpc := range sg.Vpcs {
+		if result, err := sg.manager.FetchSecurityGroupsWithVpc(vpc.ID); err != nil {
+			sg.stackError(err)
+		} else {
+			sgs = append(sgs, parseDescribeSecurityGroupsOutput(result)...)
+		}
+	}
+	sg.SecurityGroups = sgs
+	sg.resolveGroupReferences()
 	return sg.flattenErrs()
 }
 
+//resolveGroupReferences names the groups referenced by rules by their tag name or group name.
+//Groups not fetched, e.g. in another vpc or account, are left with the id.
+func (sg *SG) resolveGroupReferences() *SG {
+	names := make(map[string]string)
+	for _, v := range sg.SecurityGroups {
+		name := v.TagName
+		if name == "" {
+			name = v.GroupName
+		}
+		names[v.ID] = fmt.Sprintf("%s %s", name, v.ID)
+	}
+	for _, v := range sg.SecurityGroups {
+		for _, p := range append(append([]*IpPermission{}, v.Ingress...), v.Egress...) {
+			p.GroupNames = make([]string, 0, len(p.GroupIds))
+			for _, id := range p.GroupIds {
+				if name, ok := names[id]; ok {
+					p.GroupNames = append(p.GroupNames, name)
+				} else {
+					p.GroupNames = append(p.GroupNames, id)
+				}
+			}
+		}
+	}
+	return sg
+}
+
 func (sg *SG) constructSecurityGroups() *SG {
 	result, err := sg.manager.FetchSecurityGroups()
 	if err != nil {
@@ -71,7 +151,10 @@ func (sg *SG) constructNetworkInterfaces() *SG {
 	for _, v := range sg.SecurityGroups {
 		gids = append(gids, aws.String(v.ID))
 	}
-	result, err := sg.manager.FetchNetworkInterfaces(gids)
+	result, err := sg.manager.FetchNetworkInterfaces(&ec2.Filter{
+		Name:   aws.String("group-id"),
+		Values: gids,
+	})
 	if err != nil {
 		return sg.stackError(err)
 	}
@@ -97,105 +180,90 @@ func (sg *SG) constructNetworkInterfaces() *SG {
 }
 
 func (sg *SG) stackError(err error) *SG {
+	util.LogError(err)
 	sg.Errs = append(sg.Errs, err)
 	return sg
 }
 
 func (sg *SG) flattenErrs() error {
-	if len(sg.Errs) == 0 {
-		return nil
-	}
-	var errStr string
-	for _, e := range sg.Errs {
-		errStr = errStr + e.Error() + "\n"
-	}
-	return fmt.Errorf(errStr)
+	return svc.NewAggregateError(sg.Errs)
 }
 
 func parseDescribeSecurityGroupsOutput(output *ec2.DescribeSecurityGroupsOutput) []*SecurityGroup {
 	sgs := make([]*SecurityGroup, 0)
 	for _, v := range output.SecurityGroups {
+		if v.GroupId == nil {
+			continue
+		}
 		sg := &SecurityGroup{
 			ID:                *v.GroupId,
-			GroupName:         *v.GroupName,
+			GroupName:         stringOrDash(v.GroupName),
 			TagName:           extractTagName(v.Tags),
-			Description:       *v.Description,
+			Description:       stringOrDash(v.Description),
 			NetworkInterfaces: make([]*NetworkInterface, 0),
 		}
-		ingress := make([]*IpPermission, 0)
-		for _, i := range v.IpPermissions {
-			ip := &IpPermission{
-				Protocol: *i.IpProtocol,
-			}
-			if i.FromPort != nil {
-				ip.FromPort = *i.FromPort
-			}
-			if i.ToPort != nil {
-				ip.ToPort = *i.ToPort
-			}
-			if i.IpRanges != nil {
-				ranges := make([]string, 0)
-				for _, r := range i.IpRanges {
-					ranges = append(ranges, *r.CidrIp)
-				}
-				ip.Ranges = ranges
-			}
-			if i.UserIdGroupPairs != nil {
-				gids := make([]string, 0)
-				for _, r := range i.UserIdGroupPairs {
-					gids = append(gids, *r.GroupId)
-				}
-				ip.GroupIds = gids
-			}
-			ingress = append(ingress, ip)
+		if v.VpcId != nil {
+			sg.VpcID = *v.VpcId
 		}
-		sg.Ingress = ingress
-		egress := make([]*IpPermission, 0)
-		for _, i := range v.IpPermissionsEgress {
-			ip := &IpPermission{
-				Protocol: *i.IpProtocol,
-			}
-			if i.FromPort != nil {
-				ip.FromPort = *i.FromPort
-			}
-			if i.ToPort != nil {
-				ip.ToPort = *i.ToPort
+		sg.Ingress = parseIpPermissions(v.IpPermissions)
+		sg.Egress = parseIpPermissions(v.IpPermissionsEgress)
+		sgs = append(sgs, sg)
+	}
+	return sgs
+}
+
+func parseIpPermissions(perms []*ec2.IpPermission) []*IpPermission {
+	ips := make([]*IpPermission, 0, len(perms))
+	for _, i := range perms {
+		ip := &IpPermission{
+			Protocol: stringOrDash(i.IpProtocol),
+			Ranges:   make([]string, 0),
+			GroupIds: make([]string, 0),
+		}
+		if i.FromPort != nil {
+			ip.FromPort = *i.FromPort
+		}
+		if i.ToPort != nil {
+			ip.ToPort = *i.ToPort
+		}
+		for _, r := range i.IpRanges {
+			if r.CidrIp != nil {
+				ip.Ranges = append(ip.Ranges, *r.CidrIp)
 			}
-			if i.IpRanges != nil {
-				ranges := make([]string, 0)
-				for _, r := range i.IpRanges {
-					ranges = append(ranges, *r.CidrIp)
-				}
-				ip.Ranges = ranges
+		}
+		for _, r := range i.Ipv6Ranges {
+			if r.CidrIpv6 != nil {
+				ip.Ranges = append(ip.Ranges, *r.CidrIpv6)
 			}
-			if i.UserIdGroupPairs != nil {
-				gids := make([]string, 0)
-				for _, r := range i.UserIdGroupPairs {
-					gids = append(gids, *r.GroupId)
-				}
-				ip.GroupIds = gids
+		}
+		for _, r := range i.UserIdGroupPairs {
+			if r.GroupId != nil {
+				ip.GroupIds = append(ip.GroupIds, *r.GroupId)
 			}
-			egress = append(egress, ip)
 		}
-		sg.Egress = egress
-		sgs = append(sgs, sg)
+		ips = append(ips, ip)
 	}
-	return sgs
+	return ips
 }
 
 func parseDescribeNetworkInterfacesOutput(output *ec2.DescribeNetworkInterfacesOutput) []*NetworkInterface {
 	nis := make([]*NetworkInterface, 0)
 	for _, v := range output.NetworkInterfaces {
+		if v.NetworkInterfaceId == nil {
+			continue
+		}
 		ni := &NetworkInterface{
 			ID:          *v.NetworkInterfaceId,
-			Description: *v.Description,
+			Description: stringOrDash(v.Description),
 		}
-		if v.Attachment.InstanceId != nil {
+		if v.Attachment != nil && v.Attachment.InstanceId != nil {
 			ni.InstanceID = *v.Attachment.InstanceId
 		}
 		gids := make([]string, 0)
 		for _, g := range v.Groups {
-			gids = append(gids, *g.GroupId)
+			if g.GroupId != nil {
+				gids = append(gids, *g.GroupId)
+			}
 		}
 		ni.GroupIds = gids
 		nis = append(nis, ni)
@@ -204,13 +272,19 @@ func parseDescribeNetworkInterfacesOutput(output *ec2.DescribeNetworkInterfacesO
 }
 
 func parseDescribeInstancesOutput(output *ec2.DescribeInstancesOutput) *Instance {
+	if len(output.Reservations) == 0 || len(output.Reservations[0].Instances) == 0 {
+		return nil
+	}
 	res := output.Reservations[0].Instances[0]
 	ins := &Instance{
-		ID:               *res.InstanceId,
+		ID:               stringOrDash(res.InstanceId),
 		TagName:          extractTagName(res.Tags),
-		AvailabilityZone: *res.Placement.AvailabilityZone,
-		PrivateIP:        *res.PrivateIpAddress,
-		InstanceType:     *res.InstanceType,
+		AvailabilityZone: "-",
+		PrivateIP:        stringOrDash(res.PrivateIpAddress),
+		InstanceType:     stringOrDash(res.InstanceType),
+	}
+	if res.Placement != nil && res.Placement.AvailabilityZone != nil {
+		ins.AvailabilityZone = *res.Placement.AvailabilityZone
 	}
 	if res.PublicIpAddress != nil {
 		ins.PublicIP = *res.PublicIpAddress
@@ -238,7 +312,7 @@ func (sg *SG) convertXlsx(filename string) {
 	networkInterfaceLocation := make(map[string][2]int)
 	sg.convertNetworkInterfaceToXlsx(file, nis, instanceLocation, &networkInterfaceLocation)
 	sg.convertSecurityGroupToXlsx(file, networkInterfaceLocation)
-	if err := file.Save(fmt.Sprintf("./%s.xlsx", filename)); err != nil {
+	if err := file.Save(outputPath(filename + ".xlsx")); err != nil {
 		sg.stackError(err)
 	}
 }
@@ -360,10 +434,10 @@ func (sg *SG) convertSecurityGroupToXlsx(file *xlsx.File, refNi map[string][2]in
 		for _, i := range v.Ingress {
 			sheet.Cell(currentRow+iRow, 0).Value = i.Protocol
 			sheet.Cell(currentRow+iRow, 0).SetStyle(borderWithAlign("lr", false))
-			sheet.Cell(currentRow+iRow, 1).Value = fmt.Sprintf("%d - %d", i.FromPort, i.ToPort)
+			sheet.Cell(currentRow+iRow, 1).Value = i.portLabel()
 			sheet.Cell(currentRow+iRow, 1).SetStyle(borderWithAlign("lr", false))
 			if len(i.GroupIds) > 0 {
-				sheet.Cell(currentRow+iRow, 2).Value = strings.Join(i.GroupIds, ", ")
+				sheet.Cell(currentRow+iRow, 2).Value = strings.Join(i.GroupNames, ", ")
 			} else {
 				sheet.Cell(currentRow+iRow, 2).Value = strings.Join(i.Ranges, ", ")
 			}
@@ -374,10 +448,10 @@ func (sg *SG) convertSecurityGroupToXlsx(file *xlsx.File, refNi map[string][2]in
 		for _, e := range v.Egress {
 			sheet.Cell(currentRow+eRow, 3).Value = e.Protocol
 			sheet.Cell(currentRow+eRow, 3).SetStyle(borderWithAlign("lr", false))
-			sheet.Cell(currentRow+eRow, 4).Value = fmt.Sprintf("%d - %d", e.FromPort, e.ToPort)
+			sheet.Cell(currentRow+eRow, 4).Value = e.portLabel()
 			sheet.Cell(currentRow+eRow, 4).SetStyle(borderWithAlign("lr", false))
 			if len(e.GroupIds) > 0 {
-				sheet.Cell(currentRow+eRow, 5).Value = strings.Join(e.GroupIds, ", ")
+				sheet.Cell(currentRow+eRow, 5).Value = strings.Join(e.GroupNames, ", ")
 			} else {
 				sheet.Cell(currentRow+eRow, 5).Value = strings.Join(e.Ranges, ", ")
 			}
@@ -413,3 +487,52 @@ func (sg *SG) convertSecurityGroupToXlsx(file *xlsx.File, refNi map[string][2]in
 		currentRow++
 	}
 }
+
+func (sg *SG) convertPdf(filename, fontFile string) {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	font := pdfFont(pdf, fontFile)
+	pdf.AddPage()
+	pdf.SetFont(font, "", 10)
+	pdf.SetFillColor(255, 150, 150)
+	pdf.CellFormat(0, 10, fmt.Sprintf("Region: %s", sg.manager.Region), "", 1, "L", false, 0, "")
+	for i, vpc := range sg.Vpcs {
+		if i > 0 {
+			pdf.AddPage()
+		}
+		pdf.CellFormat(0, 10, fmt.Sprintf("%s  %s", vpc.TagName, vpc.ID), "1", 1, "C", false, 0, "")
+		pdf.Ln(5)
+		for _, v := range sg.SecurityGroups {
+			if v.VpcID != vpc.ID {
+				continue
+			}
+			pdf.CellFormat(0, 10, fitPdfText(pdf, fmt.Sprintf("%s %s, tag: %s", v.ID, v.GroupName, v.TagName), pdfContentWidth(pdf)), "1", 1, "C", false, 0, "")
+			for _, rules := range []struct {
+				title  string
+				target string
+				perms  []*IpPermission
+			}{
+				{"Inbound Rules", "Source", v.Ingress},
+				{"Outbound Rules", "Destination", v.Egress},
+			} {
+				pdf.CellFormat(0, 10, rules.title, "1", 1, "C", false, 0, "")
+				pdf.CellFormat(40, 10, "Protocol", "1", 0, "C", false, 0, "")
+				pdf.CellFormat(50, 10, "Port", "1", 0, "C", false, 0, "")
+				pdf.CellFormat(100, 10, rules.target, "1", 1, "C", false, 0, "")
+				for _, p := range rules.perms {
+					fill := p.isOpenToWorld(22) || p.isOpenToWorld(3389)
+					target := strings.Join(p.Ranges, ", ")
+					if len(p.GroupIds) > 0 {
+						target = strings.Join(p.GroupNames, ", ")
+					}
+					pdf.CellFormat(40, 10, p.Protocol, "1", 0, "C", fill, 0, "")
+					pdf.CellFormat(50, 10, p.portLabel(), "1", 0, "C", fill, 0, "")
+					pdf.CellFormat(100, 10, fitPdfText(pdf, target, 100), "1", 1, "C", fill, 0, "")
+				}
+			}
+			pdf.Ln(5)
+		}
+	}
+	if err := pdf.OutputFileAndClose(outputPath(filename + ".pdf")); err != nil {
+		sg.stackError(err)
+	}
+}
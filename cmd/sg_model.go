@@ -1,7 +1,13 @@
 package cmd
 
+import (
+	"fmt"
+	"time"
+)
+
 type SecurityGroup struct {
 	ID                string
+	VpcID             string
 	GroupName         string
 	TagName           string
 	Description       string
@@ -16,6 +22,35 @@ type IpPermission struct {
 	ToPort   int64
 	Ranges   []string
 	GroupIds []string
+	//GroupNames are the referenced groups named by resolveGroupReferences in the order of GroupIds
+	GroupNames []string
+}
+
+//portLabel is the port range of the rule, or all for all protocols
+func (ip *IpPermission) portLabel() string {
+	if ip.Protocol == "-1" {
+		return "all"
+	}
+	return fmt.Sprintf("%d - %d", ip.FromPort, ip.ToPort)
+}
+
+//isOpenToWorld reports whether the rule allows the port from 0.0.0.0/0 or ::/0
+func (ip *IpPermission) isOpenToWorld(port int64) bool {
+	switch ip.Protocol {
+	case "-1":
+	case "tcp":
+		if port < ip.FromPort || port > ip.ToPort {
+			return false
+		}
+	default:
+		return false
+	}
+	for _, r := range ip.Ranges {
+		if r == "0.0.0.0/0" || r == "::/0" {
+			return true
+		}
+	}
+	return false
 }
 
 type NetworkInterface struct {
@@ -34,6 +69,10 @@ type Instance struct {
 	InstanceType     string
 	KeyName          string
 	TagName          string
+	State            string
+	SubnetID         string
+	VpcID            string
+	LaunchTime       time.Time
 }
 
 func appendNIsWithoutDuplicate(slices, elements []*NetworkInterface) []*NetworkInterface {
@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/atsushi-ishibashi/aws-state-report/svc"
+)
+
+//tfState covers both the version 3 (modules) and version 4 (resources) state formats
+type tfState struct {
+	Version   int           `json:"version"`
+	Resources []*tfResource `json:"resources"`
+	Modules   []*tfModule   `json:"modules"`
+}
+
+type tfResource struct {
+	Module    string        `json:"module"`
+	Mode      string        `json:"mode"`
+	Type      string        `json:"type"`
+	Name      string        `json:"name"`
+	Instances []*tfInstance `json:"instances"`
+}
+
+type tfInstance struct {
+	IndexKey   interface{}            `json:"index_key"`
+	Attributes map[string]interface{} `json:"attributes"`
+}
+
+type tfModule struct {
+	Path      []string                     `json:"path"`
+	Resources map[string]*tfModuleResource `json:"resources"`
+}
+
+type tfModuleResource struct {
+	Type    string `json:"type"`
+	Primary struct {
+		ID string `json:"id"`
+	} `json:"primary"`
+}
+
+//StateResource is a managed resource in a terraform state
+type StateResource struct {
+	Type    string
+	Address string
+	ID      string
+}
+
+//loadTfState reads a local state file or an s3://bucket/key[?versionId=...] url
+func loadTfState(mng *svc.Manager, location string) ([]*StateResource, error) {
+	if !strings.HasPrefix(location, "s3://") {
+		b, err := ioutil.ReadFile(location)
+		if err != nil {
+			return nil, err
+		}
+		return parseTfState(b)
+	}
+	bucket, key, query, err := parseS3URL(location)
+	if err != nil {
+		return nil, err
+	}
+	if key == "" {
+		return nil, fmt.Errorf("invalid s3 url %s: expected s3://bucket/key", location)
+	}
+	b, err := mng.FetchObject(bucket, key, query.Get("versionId"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", location, err)
+	}
+	return parseTfState(b)
+}
+
+func parseTfState(b []byte) ([]*StateResource, error) {
+	if len(b) == 0 {
+		return []*StateResource{}, nil
+	}
+	var st tfState
+	if err := json.Unmarshal(b, &st); err != nil {
+		return nil, fmt.Errorf("failed to parse terraform state: %s", err)
+	}
+	rs := make([]*StateResource, 0)
+	if st.Version >= 4 {
+		for _, r := range st.Resources {
+			if r.Mode != "managed" {
+				continue
+			}
+			address := fmt.Sprintf("%s.%s", r.Type, r.Name)
+			if r.Module != "" {
+				address = r.Module + "." + address
+			}
+			for _, ins := range r.Instances {
+				id, _ := ins.Attributes["id"].(string)
+				if id == "" {
+					continue
+				}
+				a := address
+				switch k := ins.IndexKey.(type) {
+				case string:
+					a = fmt.Sprintf("%s[%q]", address, k)
+				case float64:
+					a = fmt.Sprintf("%s[%d]", address, int(k))
+				}
+				rs = append(rs, &StateResource{Type: r.Type, Address: a, ID: id})
+			}
+		}
+		return rs, nil
+	}
+	for _, m := range st.Modules {
+		prefix := ""
+		for _, p := range m.Path {
+			if p != "root" {
+				prefix = prefix + "module." + p + "."
+			}
+		}
+		for key, r := range m.Resources {
+			if strings.HasPrefix(key, "data.") || r.Primary.ID == "" {
+				continue
+			}
+			rs = append(rs, &StateResource{Type: r.Type, Address: prefix + key, ID: r.Primary.ID})
+		}
+	}
+	return rs, nil
+}
@@ -0,0 +1,198 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/atsushi-ishibashi/aws-state-report/svc"
+	"github.com/atsushi-ishibashi/aws-state-report/util"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/jung-kurt/gofpdf"
+	"github.com/urfave/cli"
+)
+
+func NewTopologyCommand() cli.Command {
+	return cli.Command{
+		Name:  "topology",
+		Usage: "export instances with their subnets, network interfaces and security groups in pdf file.",
+		Action: func(c *cli.Context) error {
+			if err := validateFontFile(c.GlobalString("font")); err != nil {
+				return util.ErrorRed(err.Error())
+			}
+			if err := util.ConfigAWS(c); err != nil {
+				return util.ErrorRed(err.Error())
+			}
+			ctx, cancel := withTimeout(c.GlobalDuration("timeout"))
+			defer cancel()
+			mng, err := svc.NewManager(ctx)
+			if err != nil {
+				return util.ErrorRed(err.Error())
+			}
+			t := &Topology{
+				manager:  mng,
+				fontFile: c.GlobalString("font"),
+				Errs:     make([]error, 0),
+			}
+			if err := t.recursiveConstruct(); err != nil {
+				return util.ErrorRed(err.Error())
+			}
+			if c.GlobalBool("dry-run") {
+				return nil
+			}
+			t.convertPdf()
+			if err := t.flattenErrs(); err != nil {
+				return util.ErrorRed(err.Error())
+			}
+			return nil
+		},
+	}
+}
+
+type Topology struct {
+	Subnets  []*TopologySubnet
+	manager  *svc.Manager
+	fontFile string
+	Errs     []error
+}
+
+//recursiveConstruct links the network interfaces attached to instances with their subnets and security groups
+func (t *Topology) recursiveConstruct() error {
+	instances := make(map[string]*Instance)
+	if result, err := t.manager.FetchInstances(); err != nil {
+		return t.stackError(err).flattenErrs()
+	} else {
+		for _, v := range parseDescribeInstancesOutputToInstances(result) {
+			if v.State != ec2.InstanceStateNameTerminated {
+				instances[v.ID] = v
+			}
+		}
+	}
+	sgNames := make(map[string]string)
+	if result, err := t.manager.FetchSecurityGroups(); err != nil {
+		t.stackError(err)
+	} else {
+		for _, v := range result.SecurityGroups {
+			if v.GroupId != nil {
+				sgNames[*v.GroupId] = stringOrDash(v.GroupName)
+			}
+		}
+	}
+	subnets := make(map[string]*TopologySubnet)
+	if result, err := t.manager.FetchSubnets(); err != nil {
+		return t.stackError(err).flattenErrs()
+	} else {
+		for _, v := range result.Subnets {
+			if v.SubnetId == nil {
+				continue
+			}
+			subnets[*v.SubnetId] = &TopologySubnet{
+				ID:               *v.SubnetId,
+				TagName:          extractTagName(v.Tags),
+				VpcID:            stringOrDash(v.VpcId),
+				CidrBlock:        stringOrDash(v.CidrBlock),
+				AvailabilityZone: stringOrDash(v.AvailabilityZone),
+				Attachments:      make([]*TopologyAttachment, 0),
+			}
+		}
+	}
+	result, err := t.manager.FetchNetworkInterfaces()
+	if err != nil {
+		return t.stackError(err).flattenErrs()
+	}
+	for _, v := range result.NetworkInterfaces {
+		if v.NetworkInterfaceId == nil || v.SubnetId == nil || v.Attachment == nil || v.Attachment.InstanceId == nil {
+			continue
+		}
+		ins, ok := instances[*v.Attachment.InstanceId]
+		if !ok {
+			continue
+		}
+		sn, ok := subnets[*v.SubnetId]
+		if !ok {
+			continue
+		}
+		sgs := make([]string, 0, len(v.Groups))
+		for _, g := range v.Groups {
+			if g.GroupId != nil {
+				sgs = append(sgs, fmt.Sprintf("%s %s", sgNames[*g.GroupId], *g.GroupId))
+			}
+		}
+		sn.Attachments = append(sn.Attachments, &TopologyAttachment{
+			Instance:           ins,
+			NetworkInterfaceID: *v.NetworkInterfaceId,
+			PrivateIP:          stringOrDash(v.PrivateIpAddress),
+			SecurityGroups:     sgs,
+		})
+	}
+	t.Subnets = make([]*TopologySubnet, 0)
+	for _, sn := range subnets {
+		if len(sn.Attachments) == 0 {
+			continue
+		}
+		sort.Slice(sn.Attachments, func(i, j int) bool {
+			return sn.Attachments[i].Instance.ID < sn.Attachments[j].Instance.ID
+		})
+		t.Subnets = append(t.Subnets, sn)
+	}
+	sort.Slice(t.Subnets, func(i, j int) bool {
+		a, b := t.Subnets[i], t.Subnets[j]
+		if a.VpcID != b.VpcID {
+			return a.VpcID < b.VpcID
+		}
+		if a.AvailabilityZone != b.AvailabilityZone {
+			return a.AvailabilityZone < b.AvailabilityZone
+		}
+		return a.ID < b.ID
+	})
+	return t.flattenErrs()
+}
+
+func (t *Topology) convertPdf() {
+	header := []string{"Instance", "Network Interface", "Security Groups"}
+	widths := []float64{65, 55, 70}
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	font := pdfFont(pdf, t.fontFile)
+	setPdfFooter(pdf, font)
+	pdf.AddPage()
+	pdf.SetFont(font, "", 8)
+	pdf.CellFormat(0, 10, fmt.Sprintf("Region: %s", t.manager.Region), "", 1, "L", false, 0, "")
+	printHeader := func() {
+		writePdfTableHeader(pdf, widths, header, 8)
+	}
+	for i, sn := range t.Subnets {
+		if i == 0 || sn.VpcID != t.Subnets[i-1].VpcID {
+			if i > 0 {
+				pdf.AddPage()
+			}
+			pdf.CellFormat(0, 10, sn.VpcID, "1", 1, "C", false, 0, "")
+		}
+		breakPdfPage(pdf, 26)
+		pdf.CellFormat(0, 8, fitPdfName(pdf, sn.TagName, fmt.Sprintf(" %s  %s  %s", sn.ID, sn.CidrBlock, sn.AvailabilityZone), pdfContentWidth(pdf)), "1", 1, "L", false, 0, "")
+		printHeader()
+		for _, a := range sn.Attachments {
+			row := []string{
+				fmt.Sprintf("%s\n%s %s", a.Instance.TagName, a.Instance.ID, a.Instance.State),
+				fmt.Sprintf("%s\n%s", a.NetworkInterfaceID, a.PrivateIP),
+				strings.Join(a.SecurityGroups, "\n"),
+			}
+			writePdfMultiLineRow(pdf, widths, 5, row, printHeader)
+		}
+	}
+	if len(t.Subnets) == 0 {
+		pdf.CellFormat(0, 10, "No Instances", "1", 0, "C", false, 0, "")
+	}
+	if err := pdf.OutputFileAndClose(outputPath("topology.pdf")); err != nil {
+		t.stackError(err)
+	}
+}
+
+func (t *Topology) stackError(err error) *Topology {
+	util.LogError(err)
+	t.Errs = append(t.Errs, err)
+	return t
+}
+
+func (t *Topology) flattenErrs() error {
+	return svc.NewAggregateError(t.Errs)
+}
@@ -0,0 +1,18 @@
+package cmd
+
+type TopologySubnet struct {
+	ID               string
+	TagName          string
+	VpcID            string
+	CidrBlock        string
+	AvailabilityZone string
+	Attachments      []*TopologyAttachment
+}
+
+//TopologyAttachment is a network interface attached to an instance in the subnet
+type TopologyAttachment struct {
+	Instance           *Instance
+	NetworkInterfaceID string
+	PrivateIP          string
+	SecurityGroups     []string
+}
@@ -1,13 +1,47 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"mime"
+	"net/url"
+	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/atsushi-ishibashi/aws-state-report/svc"
+	"github.com/atsushi-ishibashi/aws-state-report/util"
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/jung-kurt/gofpdf"
 	"github.com/tealeg/xlsx"
 )
 
+//OutputPrefix is prepended to the default file names of every command, e.g. ./reports/prod-
+var OutputPrefix string
+
+//outputPath returns the default path of the file with OutputPrefix
+func outputPath(name string) string {
+	if OutputPrefix == "" {
+		return "./" + name
+	}
+	return OutputPrefix + name
+}
+
+//withTimeout returns a context canceled after d. 0 means no timeout.
+func withTimeout(d time.Duration) (context.Context, context.CancelFunc) {
+	return withParentTimeout(context.Background(), d)
+}
+
+//withParentTimeout is withTimeout canceled also with parent
+func withParentTimeout(parent context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	if d <= 0 {
+		return context.WithCancel(parent)
+	}
+	return context.WithTimeout(parent, d)
+}
+
 func hyperlink(sheet string, row, col int, name string) string {
 	a, b := col/26, col%26
 	colBytes := make([]byte, 0)
@@ -21,13 +55,74 @@ func hyperlink(sheet string, row, col int, name string) string {
 func extractTagName(tags []*ec2.Tag) string {
 	var name string
 	for _, tg := range tags {
-		if *tg.Key == "Name" {
+		if tg.Key != nil && tg.Value != nil && *tg.Key == "Name" {
 			name = *tg.Value
 		}
 	}
 	return name
 }
 
+func stringOrDash(s *string) string {
+	if s == nil {
+		return "-"
+	}
+	return *s
+}
+
+//parseTagFilters converts key=value pairs into tag:key filters
+func parseTagFilters(tags []string) ([]*ec2.Filter, error) {
+	filters := make([]*ec2.Filter, 0, len(tags))
+	for _, t := range tags {
+		kv := strings.SplitN(t, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, fmt.Errorf("invalid tag %q: expected key=value", t)
+		}
+		filters = append(filters, &ec2.Filter{
+			Name:   aws.String("tag:" + kv[0]),
+			Values: []*string{aws.String(kv[1])},
+		})
+	}
+	return filters, nil
+}
+
+//splitList splits the comma separated values, dropping empty ones
+func splitList(s string) []string {
+	values := make([]string, 0)
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+func contentType(ext string) string {
+	switch ext {
+	case ".md":
+		return "text/markdown; charset=utf-8"
+	case ".dot":
+		return "text/vnd.graphviz"
+	case ".xlsx":
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	if t := mime.TypeByExtension(ext); t != "" {
+		return t
+	}
+	return "application/octet-stream"
+}
+
+//parseS3URL splits s3://bucket/key?query. The key may be empty.
+func parseS3URL(location string) (string, string, url.Values, error) {
+	u, err := url.Parse(location)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("invalid s3 url %s: %s", location, err)
+	}
+	if u.Scheme != "s3" || u.Host == "" {
+		return "", "", nil, fmt.Errorf("invalid s3 url %s: expected s3://bucket/key", location)
+	}
+	return u.Host, strings.TrimPrefix(u.Path, "/"), u.Query(), nil
+}
+
 func borderWithAlign(lrtb string, isAlign bool) *xlsx.Style {
 	b := xlsx.Border{}
 	btype := "thin"
@@ -53,3 +148,262 @@ func borderWithAlign(lrtb string, isAlign bool) *xlsx.Style {
 	}
 	return st
 }
+
+func prepareOutputPath(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	_, statErr := os.Stat(path)
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE, 0644)
+	if err != nil {
+		return fmt.Errorf("%s is not writable: %s", path, err)
+	}
+	f.Close()
+	if os.IsNotExist(statErr) {
+		return os.Remove(path)
+	}
+	return nil
+}
+
+//breakPdfPage adds a page when a row of height h does not fit in the current page.
+//The bottom border of the current table is drawn before the break.
+func breakPdfPage(pdf *gofpdf.Fpdf, h float64) bool {
+	_, pageHeight := pdf.GetPageSize()
+	_, bottomMargin := pdf.GetAutoPageBreak()
+	if pdf.GetY()+h <= pageHeight-bottomMargin {
+		return false
+	}
+	pdf.CellFormat(0, 0, "", "T", 1, "C", false, 0, "")
+	pdf.AddPage()
+	return true
+}
+
+func validatePageSize(size string) error {
+	switch strings.ToLower(size) {
+	case "a4", "a3", "letter":
+		return nil
+	}
+	return fmt.Errorf("unknown page size: %s. one of A4, A3, Letter", size)
+}
+
+func pdfContentWidth(pdf *gofpdf.Fpdf) float64 {
+	pageWidth, _ := pdf.GetPageSize()
+	left, _, right, _ := pdf.GetMargins()
+	return pageWidth - left - right
+}
+
+//scalePdfWidths stretches column widths laid out for the portrait content width of 190mm to the current page
+func scalePdfWidths(pdf *gofpdf.Fpdf, widths []float64) []float64 {
+	ratio := pdfContentWidth(pdf) / 190
+	scaled := make([]float64, len(widths))
+	for i, w := range widths {
+		scaled[i] = w * ratio
+	}
+	return scaled
+}
+
+//TruncateLength is the max number of characters of each line of pdf cells. 0 means no limit.
+var TruncateLength int
+
+const ellipsis = "..."
+
+func truncateText(s string, n int) string {
+	runes := []rune(s)
+	if n <= 0 || len(runes) <= n {
+		return s
+	}
+	if n <= len(ellipsis) {
+		return string(runes[:n])
+	}
+	return string(runes[:n-len(ellipsis)]) + ellipsis
+}
+
+//fitPdfText truncates s to TruncateLength, and further with an ellipsis so that it fits in a cell of width w
+func fitPdfText(pdf *gofpdf.Fpdf, s string, w float64) string {
+	s = truncateText(s, TruncateLength)
+	max := w - 2*pdf.GetCellMargin()
+	if pdf.GetStringWidth(s) <= max {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 && pdf.GetStringWidth(string(runes)+ellipsis) > max {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + ellipsis
+}
+
+//fitPdfName truncates only the name so that the name followed by rest fits in a cell of width w
+func fitPdfName(pdf *gofpdf.Fpdf, name, rest string, w float64) string {
+	return fitPdfText(pdf, name, w-pdf.GetStringWidth(rest)) + rest
+}
+
+//writePdfTableHeader writes the header row in black. The text color is restored
+//since the header may be repeated after a page break while a red row is written.
+func writePdfTableHeader(pdf *gofpdf.Fpdf, widths []float64, header []string, height float64) {
+	r, g, b := pdf.GetTextColor()
+	pdf.SetTextColor(0, 0, 0)
+	for i, h := range header {
+		pdf.CellFormat(widths[i], height, h, "1", 0, "C", false, 0, "")
+	}
+	pdf.Ln(-1)
+	pdf.SetTextColor(r, g, b)
+}
+
+//writePdfMultiLineRow writes a row whose columns wrap within their widths.
+//printHeader is called after a page break so that the table header is repeated on the new page.
+func writePdfMultiLineRow(pdf *gofpdf.Fpdf, widths []float64, lineHeight float64, row []string, printHeader func()) {
+	lines := 1
+	if TruncateLength > 0 {
+		truncated := make([]string, len(row))
+		for i, col := range row {
+			colLines := strings.Split(col, "\n")
+			for j, l := range colLines {
+				colLines[j] = truncateText(l, TruncateLength)
+			}
+			truncated[i] = strings.Join(colLines, "\n")
+		}
+		row = truncated
+	}
+	for i, col := range row {
+		if n := len(pdf.SplitLines([]byte(col), widths[i]-2)); n > lines {
+			lines = n
+		}
+	}
+	h := lineHeight * float64(lines)
+	if breakPdfPage(pdf, h) && printHeader != nil {
+		printHeader()
+	}
+	left, y := pdf.GetXY()
+	x := left
+	for i, col := range row {
+		pdf.Rect(x, y, widths[i], h, "D")
+		pdf.SetXY(x, y)
+		pdf.MultiCell(widths[i], lineHeight, col, "", "L", false)
+		x += widths[i]
+	}
+	pdf.SetXY(left, y+h)
+}
+
+//pdfFont registers fontFile as an utf-8 font and returns its family name.
+//Arial, which supports only latin-1, is returned when fontFile is empty.
+func pdfFont(pdf *gofpdf.Fpdf, fontFile string) string {
+	if fontFile == "" {
+		return "Arial"
+	}
+	pdf.AddUTF8Font("custom", "", fontFile)
+	pdf.AddUTF8Font("custom", "B", fontFile)
+	return "custom"
+}
+
+func validateFontFile(fontFile string) error {
+	if fontFile == "" {
+		return nil
+	}
+	if _, err := os.Stat(fontFile); err != nil {
+		return fmt.Errorf("font file %s is not found: %s", fontFile, err)
+	}
+	return nil
+}
+
+//setPdfFooter prints the generation time and version on the left and the page number on the right of every page
+func setPdfFooter(pdf *gofpdf.Fpdf, font string) {
+	generatedAt := fmt.Sprintf("%s  aws-state-report %s", time.Now().UTC().Format("2006-01-02 15:04:05 UTC"), Version)
+	pdf.AliasNbPages("")
+	pdf.SetFooterFunc(func() {
+		left, _, _, _ := pdf.GetMargins()
+		pdf.SetY(-15)
+		pdf.SetFont(font, "", 8)
+		pdf.CellFormat(0, 10, generatedAt, "", 0, "L", false, 0, "")
+		pdf.SetX(left)
+		pdf.CellFormat(0, 10, fmt.Sprintf("Page %d of {nb}", pdf.PageNo()), "", 0, "R", false, 0, "")
+	})
+}
+
+//setPdfHeader prints the account label on the right of the top of every page
+func setPdfHeader(pdf *gofpdf.Fpdf, font, account string) {
+	pdf.SetHeaderFunc(func() {
+		pdf.SetFont(font, "B", 10)
+		pdf.CellFormat(0, 8, account, "B", 1, "R", false, 0, "")
+		pdf.Ln(2)
+	})
+}
+
+//accountLabel returns alias, the first iam account alias or the account id in this order.
+//A failure of the iam call, e.g. without iam:ListAccountAliases, falls back to the account id.
+func accountLabel(mng *svc.Manager, alias string) (string, error) {
+	if alias != "" {
+		return alias, nil
+	}
+	if aliases, err := mng.FetchAccountAliases(); err != nil {
+		util.Debugf("failed to list account aliases, using the account id: %s", err)
+	} else if len(aliases.AccountAliases) > 0 && aliases.AccountAliases[0] != nil {
+		return *aliases.AccountAliases[0], nil
+	}
+	identity, err := mng.FetchCallerIdentity()
+	if err != nil {
+		return "", err
+	}
+	return *identity.Account, nil
+}
+
+const tocRowHeight = 8.0
+
+//pdfToc is a table of contents whose pages are reserved before the contents are written
+type pdfToc struct {
+	pdf       *gofpdf.Fpdf
+	font      string
+	firstPage int
+	top       float64
+	titles    []string
+	pages     []int
+	links     []int
+}
+
+func newPdfToc(pdf *gofpdf.Fpdf, font string, entries int) *pdfToc {
+	_, pageHeight := pdf.GetPageSize()
+	_, _, _, bottom := pdf.GetMargins()
+	pdf.AddPage()
+	//the contents start below the page header if any
+	toc := &pdfToc{pdf: pdf, font: font, firstPage: pdf.PageNo(), top: pdf.GetY()}
+	rowsPerPage := int((pageHeight-toc.top-bottom)/tocRowHeight) - 2
+	for i := 0; i < entries/rowsPerPage; i++ {
+		pdf.AddPage()
+	}
+	return toc
+}
+
+//mark records the current page as the start of a section titled title
+func (t *pdfToc) mark(title string) {
+	link := t.pdf.AddLink()
+	t.pdf.SetLink(link, -1, -1)
+	t.titles = append(t.titles, title)
+	t.pages = append(t.pages, t.pdf.PageNo())
+	t.links = append(t.links, link)
+}
+
+func (t *pdfToc) render() {
+	lastPage := t.pdf.PageNo()
+	auto, margin := t.pdf.GetAutoPageBreak()
+	t.pdf.SetAutoPageBreak(false, 0)
+	defer func() {
+		t.pdf.SetAutoPageBreak(auto, margin)
+		t.pdf.SetPage(lastPage)
+	}()
+	_, pageHeight := t.pdf.GetPageSize()
+	_, _, _, bottom := t.pdf.GetMargins()
+	page := t.firstPage
+	t.pdf.SetPage(page)
+	t.pdf.SetY(t.top)
+	t.pdf.SetFont(t.font, "B", 12)
+	t.pdf.CellFormat(0, tocRowHeight*2, "Contents", "", 1, "L", false, 0, "")
+	t.pdf.SetFont(t.font, "", 10)
+	for i, title := range t.titles {
+		if t.pdf.GetY()+tocRowHeight > pageHeight-bottom {
+			page++
+			t.pdf.SetPage(page)
+			t.pdf.SetY(t.top)
+		}
+		t.pdf.CellFormat(pdfContentWidth(t.pdf)-20, tocRowHeight, title, "", 0, "L", false, t.links[i], "")
+		t.pdf.CellFormat(0, tocRowHeight, fmt.Sprintf("%d", t.pages[i]), "", 1, "R", false, t.links[i], "")
+	}
+}
@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/urfave/cli"
+)
+
+//Version, Commit and BuildDate are set by -ldflags "-X github.com/atsushi-ishibashi/aws-state-report/cmd.Version=..."
+var (
+	Version   = "dev"
+	Commit    = "unknown"
+	BuildDate = "unknown"
+)
+
+func NewVersionCommand() cli.Command {
+	return cli.Command{
+		Name:  "version",
+		Usage: "print the version, commit and build date.",
+		Action: func(c *cli.Context) error {
+			fmt.Println(versionString())
+			return nil
+		},
+	}
+}
+
+func versionString() string {
+	return fmt.Sprintf("aws-state-report %s (commit %s, built %s)", Version, Commit, BuildDate)
+}
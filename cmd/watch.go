@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/atsushi-ishibashi/aws-state-report/util"
+)
+
+//watchReport calls run every interval until SIGINT or SIGTERM.
+//The errors of each run are printed and do not stop the loop.
+//A signal cancels the context of the running cycle and stops the loop after it returns.
+func watchReport(interval time.Duration, run func(ctx context.Context) error) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sig)
+	stopped := make(chan os.Signal, 1)
+	go func() {
+		select {
+		case s := <-sig:
+			stopped <- s
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		next := time.Now().Add(interval)
+		if err := run(ctx); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		if ctx.Err() == nil {
+			util.PrintlnGreen(fmt.Sprintf("next report at %s", next.Format("15:04:05")))
+		}
+		select {
+		case <-ctx.Done():
+			util.PrintlnGreen(fmt.Sprintf("stopped watching by %s", <-stopped))
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
@@ -1,36 +1,167 @@
 package main
 
 import (
+	"fmt"
 	"os"
+	"path/filepath"
+	"time"
 
 	"github.com/atsushi-ishibashi/aws-state-report/cmd"
+	"github.com/atsushi-ishibashi/aws-state-report/svc"
+	"github.com/atsushi-ishibashi/aws-state-report/util"
 	"github.com/urfave/cli"
 )
 
 func main() {
 
 	app := cli.NewApp()
+	app.Version = cmd.Version
 
 	app.Flags = []cli.Flag{
 		cli.StringFlag{
-			Name:  "awsconf",
-			Usage: "~/.aws/credentialsから環境変数をセット(プロセスの間のみ)",
+			Name:  "awsconf, profile",
+			Usage: "~/.aws/credentials, ~/.aws/configのprofileから環境変数をセット(プロセスの間のみ)",
+		},
+		cli.BoolFlag{
+			Name:  "profile-from-env",
+			Usage: "--profile省略時に環境変数AWS_PROFILEのprofileを使うことを強制(未設定ならエラー)",
 		},
 		cli.StringFlag{
-			Name:  "awsregion",
+			Name:  "awsregion, region",
 			Usage: "AWS_DEFAULT_REGIONにセット(プロセスの間のみ)",
 			Value: "ap-northeast-1",
 		},
+		cli.StringFlag{
+			Name:  "assume-role-arn",
+			Usage: "assume roleしたcredentialsを環境変数にセット(プロセスの間のみ)",
+		},
+		cli.StringFlag{
+			Name:  "external-id",
+			Usage: "assume role時のexternal id",
+		},
+		cli.StringFlag{
+			Name:  "role-session-name",
+			Usage: "assume role時のsession name",
+			Value: "aws-state-report",
+		},
+		cli.StringFlag{
+			Name:  "font",
+			Usage: "PDFに使うTTFフォントファイル(日本語などUTF-8のタグ名を表示する場合に指定)",
+		},
+		cli.StringFlag{
+			Name:  "account-alias",
+			Usage: "networkレポートのヘッダーに表示するアカウント名(省略時はIAMのアカウントエイリアス、なければアカウントID)",
+		},
+		cli.BoolFlag{
+			Name:  "quiet, q",
+			Usage: "エラー以外の出力を抑制",
+		},
+		cli.BoolFlag{
+			Name:  "no-color",
+			Usage: "エラーなどの出力に色(ANSIエスケープシーケンス)を付けない。端末以外への出力やNO_COLOR設定時は自動で無効",
+		},
+		cli.BoolFlag{
+			Name:  "verbose, V",
+			Usage: "各APIの呼び出し、取得件数、所要時間、エラーを標準エラー出力にログ出力",
+		},
+		cli.IntFlag{
+			Name:  "max-retries",
+			Usage: "スロットリングなどで失敗したAPI呼び出しを指数バックオフでリトライする回数",
+			Value: 10,
+		},
+		cli.IntFlag{
+			Name:  "concurrency",
+			Usage: "並行して取得するvpcやregionの数。スロットリングが多いアカウントでは小さくする",
+			Value: 5,
+		},
+		cli.DurationFlag{
+			Name:  "timeout",
+			Usage: "全体のタイムアウト。超えた場合はそれまでに取得したリソースでレポートを出力(0で無制限)",
+			Value: 5 * time.Minute,
+		},
+		cli.BoolFlag{
+			Name:  "dry-run",
+			Usage: "呼び出すAPIを表示するのみで実行せず、ファイルも出力しない",
+		},
+		cli.StringFlag{
+			Name:  "cache-dir",
+			Usage: "Describe*などの参照APIのレスポンスをJSONで保存するディレクトリ",
+		},
+		cli.BoolFlag{
+			Name:  "from-cache",
+			Usage: "AWSを呼び出さず--cache-dirに保存したレスポンスからレポートを出力",
+		},
+		cli.IntFlag{
+			Name:  "truncate",
+			Usage: "PDFのセルに表示するタグ名などの最大文字数(0で無制限。列幅を超える場合は常に...で省略)",
+		},
+		cli.StringFlag{
+			Name:  "output-prefix",
+			Usage: "各コマンドの既定のファイル名の前に付けるパス(例: ./reports/prod- で ./reports/prod-network.pdf)",
+		},
+	}
+	app.Before = func(c *cli.Context) error {
+		if c.Bool("no-color") {
+			util.NoColor = true
+		}
+		svc.DryRun = c.Bool("dry-run")
+		util.Verbose = c.Bool("verbose")
+		util.Quiet = c.Bool("quiet")
+		svc.MaxRetries = c.Int("max-retries")
+		if c.Int("concurrency") < 1 {
+			return util.ErrorRed(fmt.Sprintf("--concurrency must be 1 or more: %d", c.Int("concurrency")))
+		}
+		svc.Concurrency = c.Int("concurrency")
+		svc.CacheDir = c.String("cache-dir")
+		svc.FromCache = c.Bool("from-cache")
+		if svc.FromCache && svc.CacheDir == "" {
+			return util.ErrorRed("--from-cache requires --cache-dir")
+		}
+		cmd.TruncateLength = c.Int("truncate")
+		cmd.OutputPrefix = c.String("output-prefix")
+		if cmd.OutputPrefix != "" && !svc.DryRun {
+			if err := os.MkdirAll(filepath.Dir(cmd.OutputPrefix), 0755); err != nil {
+				return util.ErrorRed(err.Error())
+			}
+		}
+		return nil
 	}
 
 	networkCommand := cmd.NewNetworkCommand()
 	iamCommand := cmd.NewIAMCommand()
 	sgCommand := cmd.NewSGCommand()
+	ec2Command := cmd.NewEC2Command()
+	route53Command := cmd.NewRoute53Command()
+	elbCommand := cmd.NewELBCommand()
+	driftCommand := cmd.NewDriftCommand()
+	permissionsCommand := cmd.NewPermissionsCommand()
+	versionCommand := cmd.NewVersionCommand()
+	topologyCommand := cmd.NewTopologyCommand()
+	rdsCommand := cmd.NewRDSCommand()
+	s3Command := cmd.NewS3Command()
+	ecsCommand := cmd.NewECSCommand()
+	diffCommand := cmd.NewDiffCommand()
+	configCommand := cmd.NewConfigCommand()
 
 	app.Commands = []cli.Command{
 		networkCommand,
 		iamCommand,
 		sgCommand,
+		ec2Command,
+		route53Command,
+		elbCommand,
+		driftCommand,
+		diffCommand,
+		permissionsCommand,
+		topologyCommand,
+		rdsCommand,
+		s3Command,
+		ecsCommand,
+		configCommand,
+		versionCommand,
+	}
+	if err := app.Run(os.Args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
 	}
-	app.Run(os.Args)
 }
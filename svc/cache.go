@@ -0,0 +1,97 @@
+package svc
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/atsushi-ishibashi/aws-state-report/util"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+)
+
+//CacheDir makes the clients of managers created afterwards save the responses of read apis under the directory
+var CacheDir string
+
+//FromCache makes the clients of managers created afterwards read the responses from CacheDir instead of calling aws.
+//Calls which are not cached fail.
+var FromCache bool
+
+//cacheable reports whether the operation only reads resources and its response can be saved as json
+func cacheable(r *request.Request) bool {
+	name := r.Operation.Name
+	return strings.HasPrefix(name, "Describe") || strings.HasPrefix(name, "List") ||
+		name == "GetCallerIdentity" || name == "GetManagedPrefixListEntries"
+}
+
+//cachePath is unique to the region, operation and parameters including the page token
+func cachePath(r *request.Request) (string, error) {
+	params, err := json.Marshal(r.Params)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(params)
+	file := fmt.Sprintf("%s-%x.json", r.Operation.Name, sum[:8])
+	return filepath.Join(CacheDir, aws.StringValue(r.Config.Region), r.ClientInfo.ServiceName, file), nil
+}
+
+var cacheWriteHandler = request.NamedHandler{
+	Name: "awsstatereport.CacheWriteHandler",
+	Fn: func(r *request.Request) {
+		if r.Error != nil || !cacheable(r) {
+			return
+		}
+		if err := writeCache(r); err != nil {
+			util.LogError(fmt.Errorf("failed to cache %s:%s: %s", r.ClientInfo.ServiceName, r.Operation.Name, err))
+		}
+	},
+}
+
+func writeCache(r *request.Request) error {
+	path, err := cachePath(r)
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(r.Data)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, b, 0644)
+}
+
+var cacheReadHandler = request.NamedHandler{
+	Name: "awsstatereport.CacheReadHandler",
+	Fn: func(r *request.Request) {
+		op := fmt.Sprintf("%s:%s (%s)", r.ClientInfo.ServiceName, r.Operation.Name, aws.StringValue(r.Config.Region))
+		if !cacheable(r) {
+			r.Error = fmt.Errorf("%s cannot be called with --from-cache", op)
+			return
+		}
+		path, err := cachePath(r)
+		if err != nil {
+			r.Error = err
+			return
+		}
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			r.Error = fmt.Errorf("%s is not cached: %s", op, err)
+			return
+		}
+		if err := json.Unmarshal(b, r.Data); err != nil {
+			r.Error = fmt.Errorf("broken cache of %s: %s", op, err)
+			return
+		}
+		r.Handlers.Sign.Clear()
+		r.Handlers.Send.Clear()
+		r.Handlers.UnmarshalMeta.Clear()
+		r.Handlers.ValidateResponse.Clear()
+		r.Handlers.Unmarshal.Clear()
+	},
+}
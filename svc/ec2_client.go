@@ -3,15 +3,53 @@ package svc
 import (
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
 )
 
 type EC2Client struct {
-	*ec2.EC2
+	ec2iface.EC2API
+	ctx aws.Context
 }
 
-func (c *EC2Client) FetchVpcs() (*ec2.DescribeVpcsOutput, error) {
+func (c *EC2Client) FetchVpcs(filters ...*ec2.Filter) (*ec2.DescribeVpcsOutput, error) {
 	input := &ec2.DescribeVpcsInput{}
-	return c.DescribeVpcs(input)
+	if len(filters) > 0 {
+		input.Filters = filters
+	}
+	return c.fetchVpcsPages(input)
+}
+
+func (c *EC2Client) FetchVpcsWithIds(vpcIDs []string) (*ec2.DescribeVpcsOutput, error) {
+	input := &ec2.DescribeVpcsInput{
+		VpcIds: aws.StringSlice(vpcIDs),
+	}
+	return c.fetchVpcsPages(input)
+}
+
+func (c *EC2Client) fetchVpcsPages(input *ec2.DescribeVpcsInput) (*ec2.DescribeVpcsOutput, error) {
+	output := &ec2.DescribeVpcsOutput{}
+	err := c.DescribeVpcsPagesWithContext(c.ctx, input, func(page *ec2.DescribeVpcsOutput, lastPage bool) bool {
+		output.Vpcs = append(output.Vpcs, page.Vpcs...)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+//FetchRegions fetches the regions enabled for the account
+func (c *EC2Client) FetchRegions() (*ec2.DescribeRegionsOutput, error) {
+	input := &ec2.DescribeRegionsInput{
+		AllRegions: aws.Bool(false),
+		Filters: []*ec2.Filter{
+			&ec2.Filter{
+				Name:   aws.String("opt-in-status"),
+				Values: aws.StringSlice([]string{"opt-in-not-required", "opted-in"}),
+			},
+		},
+	}
+	return c.DescribeRegionsWithContext(c.ctx, input)
 }
 
 func (c *EC2Client) FetchRouteTablesWithVpc(vpcID string) (*ec2.DescribeRouteTablesOutput, error) {
@@ -23,11 +61,162 @@ func (c *EC2Client) FetchRouteTablesWithVpc(vpcID string) (*ec2.DescribeRouteTab
 			},
 		},
 	}
-	return c.DescribeRouteTables(input)
+	output := &ec2.DescribeRouteTablesOutput{}
+	err := c.DescribeRouteTablesPagesWithContext(c.ctx, input, func(page *ec2.DescribeRouteTablesOutput, lastPage bool) bool {
+		output.RouteTables = append(output.RouteTables, page.RouteTables...)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return output, nil
 }
 
-func (c *EC2Client) FetchSubnetsWithVpc(vpcID string) (*ec2.DescribeSubnetsOutput, error) {
+func (c *EC2Client) FetchSubnetsWithVpc(vpcID string, filters ...*ec2.Filter) (*ec2.DescribeSubnetsOutput, error) {
 	input := &ec2.DescribeSubnetsInput{
+		Filters: append([]*ec2.Filter{
+			&ec2.Filter{
+				Name:   aws.String("vpc-id"),
+				Values: []*string{aws.String(vpcID)},
+			},
+		}, filters...),
+	}
+	output := &ec2.DescribeSubnetsOutput{}
+	err := c.DescribeSubnetsPagesWithContext(c.ctx, input, func(page *ec2.DescribeSubnetsOutput, lastPage bool) bool {
+		output.Subnets = append(output.Subnets, page.Subnets...)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+func (c *EC2Client) FetchSubnets() (*ec2.DescribeSubnetsOutput, error) {
+	input := &ec2.DescribeSubnetsInput{}
+	output := &ec2.DescribeSubnetsOutput{}
+	err := c.DescribeSubnetsPagesWithContext(c.ctx, input, func(page *ec2.DescribeSubnetsOutput, lastPage bool) bool {
+		output.Subnets = append(output.Subnets, page.Subnets...)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+func (c *EC2Client) FetchNetworkInterfaces(filters ...*ec2.Filter) (*ec2.DescribeNetworkInterfacesOutput, error) {
+	input := &ec2.DescribeNetworkInterfacesInput{}
+	if len(filters) > 0 {
+		input.Filters = filters
+	}
+	output := &ec2.DescribeNetworkInterfacesOutput{}
+	err := c.DescribeNetworkInterfacesPagesWithContext(c.ctx, input, func(page *ec2.DescribeNetworkInterfacesOutput, lastPage bool) bool {
+		output.NetworkInterfaces = append(output.NetworkInterfaces, page.NetworkInterfaces...)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+func (c *EC2Client) FetchNetworkInterfacesWithVpc(vpcID string) (*ec2.DescribeNetworkInterfacesOutput, error) {
+	return c.FetchNetworkInterfaces(&ec2.Filter{
+		Name:   aws.String("vpc-id"),
+		Values: []*string{aws.String(vpcID)},
+	})
+}
+
+func (c *EC2Client) FetchInstances(filters ...*ec2.Filter) (*ec2.DescribeInstancesOutput, error) {
+	input := &ec2.DescribeInstancesInput{}
+	if len(filters) > 0 {
+		input.Filters = filters
+	}
+	output := &ec2.DescribeInstancesOutput{}
+	err := c.DescribeInstancesPagesWithContext(c.ctx, input, func(page *ec2.DescribeInstancesOutput, lastPage bool) bool {
+		output.Reservations = append(output.Reservations, page.Reservations...)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+func (c *EC2Client) FetchInternetGateways() (*ec2.DescribeInternetGatewaysOutput, error) {
+	input := &ec2.DescribeInternetGatewaysInput{}
+	output := &ec2.DescribeInternetGatewaysOutput{}
+	err := c.DescribeInternetGatewaysPagesWithContext(c.ctx, input, func(page *ec2.DescribeInternetGatewaysOutput, lastPage bool) bool {
+		output.InternetGateways = append(output.InternetGateways, page.InternetGateways...)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+func (c *EC2Client) FetchNatGateways() (*ec2.DescribeNatGatewaysOutput, error) {
+	input := &ec2.DescribeNatGatewaysInput{}
+	output := &ec2.DescribeNatGatewaysOutput{}
+	err := c.DescribeNatGatewaysPagesWithContext(c.ctx, input, func(page *ec2.DescribeNatGatewaysOutput, lastPage bool) bool {
+		output.NatGateways = append(output.NatGateways, page.NatGateways...)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+//FetchVpnGateways is not paginated by the api
+func (c *EC2Client) FetchVpnGateways() (*ec2.DescribeVpnGatewaysOutput, error) {
+	input := &ec2.DescribeVpnGatewaysInput{}
+	return c.DescribeVpnGatewaysWithContext(c.ctx, input)
+}
+
+//FetchAddresses is not paginated by the api
+func (c *EC2Client) FetchAddresses() (*ec2.DescribeAddressesOutput, error) {
+	input := &ec2.DescribeAddressesInput{}
+	return c.DescribeAddressesWithContext(c.ctx, input)
+}
+
+func (c *EC2Client) FetchVpcPeeringConnections() (*ec2.DescribeVpcPeeringConnectionsOutput, error) {
+	input := &ec2.DescribeVpcPeeringConnectionsInput{}
+	output := &ec2.DescribeVpcPeeringConnectionsOutput{}
+	err := c.DescribeVpcPeeringConnectionsPagesWithContext(c.ctx, input, func(page *ec2.DescribeVpcPeeringConnectionsOutput, lastPage bool) bool {
+		output.VpcPeeringConnections = append(output.VpcPeeringConnections, page.VpcPeeringConnections...)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+func (c *EC2Client) FetchNetworkAclsWithVpc(vpcID string) (*ec2.DescribeNetworkAclsOutput, error) {
+	input := &ec2.DescribeNetworkAclsInput{
+		Filters: []*ec2.Filter{
+			&ec2.Filter{
+				Name:   aws.String("vpc-id"),
+				Values: []*string{aws.String(vpcID)},
+			},
+		},
+	}
+	output := &ec2.DescribeNetworkAclsOutput{}
+	err := c.DescribeNetworkAclsPagesWithContext(c.ctx, input, func(page *ec2.DescribeNetworkAclsOutput, lastPage bool) bool {
+		output.NetworkAcls = append(output.NetworkAcls, page.NetworkAcls...)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+func (c *EC2Client) FetchVpcEndpointsWithVpc(vpcID string) (*ec2.DescribeVpcEndpointsOutput, error) {
+	input := &ec2.DescribeVpcEndpointsInput{
 		Filters: []*ec2.Filter{
 			&ec2.Filter{
 				Name:   aws.String("vpc-id"),
@@ -35,5 +224,93 @@ func (c *EC2Client) FetchSubnetsWithVpc(vpcID string) (*ec2.DescribeSubnetsOutpu
 			},
 		},
 	}
-	return c.DescribeSubnets(input)
+	output := &ec2.DescribeVpcEndpointsOutput{}
+	err := c.DescribeVpcEndpointsPagesWithContext(c.ctx, input, func(page *ec2.DescribeVpcEndpointsOutput, lastPage bool) bool {
+		output.VpcEndpoints = append(output.VpcEndpoints, page.VpcEndpoints...)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+func (c *EC2Client) FetchDhcpOptions() (*ec2.DescribeDhcpOptionsOutput, error) {
+	input := &ec2.DescribeDhcpOptionsInput{}
+	output := &ec2.DescribeDhcpOptionsOutput{}
+	err := c.DescribeDhcpOptionsPagesWithContext(c.ctx, input, func(page *ec2.DescribeDhcpOptionsOutput, lastPage bool) bool {
+		output.DhcpOptions = append(output.DhcpOptions, page.DhcpOptions...)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+func (c *EC2Client) FetchFlowLogs() (*ec2.DescribeFlowLogsOutput, error) {
+	input := &ec2.DescribeFlowLogsInput{}
+	output := &ec2.DescribeFlowLogsOutput{}
+	err := c.DescribeFlowLogsPagesWithContext(c.ctx, input, func(page *ec2.DescribeFlowLogsOutput, lastPage bool) bool {
+		output.FlowLogs = append(output.FlowLogs, page.FlowLogs...)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+func (c *EC2Client) FetchTransitGateways() (*ec2.DescribeTransitGatewaysOutput, error) {
+	input := &ec2.DescribeTransitGatewaysInput{}
+	output := &ec2.DescribeTransitGatewaysOutput{}
+	err := c.DescribeTransitGatewaysPagesWithContext(c.ctx, input, func(page *ec2.DescribeTransitGatewaysOutput, lastPage bool) bool {
+		output.TransitGateways = append(output.TransitGateways, page.TransitGateways...)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+func (c *EC2Client) FetchTransitGatewayAttachments() (*ec2.DescribeTransitGatewayAttachmentsOutput, error) {
+	input := &ec2.DescribeTransitGatewayAttachmentsInput{}
+	output := &ec2.DescribeTransitGatewayAttachmentsOutput{}
+	err := c.DescribeTransitGatewayAttachmentsPagesWithContext(c.ctx, input, func(page *ec2.DescribeTransitGatewayAttachmentsOutput, lastPage bool) bool {
+		output.TransitGatewayAttachments = append(output.TransitGatewayAttachments, page.TransitGatewayAttachments...)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+func (c *EC2Client) FetchManagedPrefixLists() (*ec2.DescribeManagedPrefixListsOutput, error) {
+	input := &ec2.DescribeManagedPrefixListsInput{}
+	output := &ec2.DescribeManagedPrefixListsOutput{}
+	err := c.DescribeManagedPrefixListsPagesWithContext(c.ctx, input, func(page *ec2.DescribeManagedPrefixListsOutput, lastPage bool) bool {
+		output.PrefixLists = append(output.PrefixLists, page.PrefixLists...)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+func (c *EC2Client) FetchManagedPrefixListEntries(prefixListID string) (*ec2.GetManagedPrefixListEntriesOutput, error) {
+	input := &ec2.GetManagedPrefixListEntriesInput{
+		PrefixListId: aws.String(prefixListID),
+	}
+	output := &ec2.GetManagedPrefixListEntriesOutput{}
+	err := c.GetManagedPrefixListEntriesPagesWithContext(c.ctx, input, func(page *ec2.GetManagedPrefixListEntriesOutput, lastPage bool) bool {
+		output.Entries = append(output.Entries, page.Entries...)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return output, nil
 }
@@ -0,0 +1,51 @@
+package svc
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+func TestFetchSubnetsPages(t *testing.T) {
+	fake := &fakeEC2{
+		subnetPages: []*ec2.DescribeSubnetsOutput{
+			{
+				Subnets:   []*ec2.Subnet{{SubnetId: aws.String("subnet-1")}, {SubnetId: aws.String("subnet-2")}},
+				NextToken: aws.String("page-2"),
+			},
+			{
+				Subnets: []*ec2.Subnet{{SubnetId: aws.String("subnet-3")}},
+			},
+		},
+	}
+	c := newFakeManager(fake).EC2Client
+	tests := []struct {
+		name  string
+		fetch func() (*ec2.DescribeSubnetsOutput, error)
+	}{
+		{"FetchSubnetsWithVpc", func() (*ec2.DescribeSubnetsOutput, error) { return c.FetchSubnetsWithVpc("vpc-1") }},
+		{"FetchSubnets", c.FetchSubnets},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := tt.fetch()
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make([]string, 0, len(output.Subnets))
+			for _, sn := range output.Subnets {
+				got = append(got, *sn.SubnetId)
+			}
+			want := []string{"subnet-1", "subnet-2", "subnet-3"}
+			if len(got) != len(want) {
+				t.Fatalf("got %v, want %v", got, want)
+			}
+			for i := range want {
+				if got[i] != want[i] {
+					t.Errorf("got %v, want %v", got, want)
+				}
+			}
+		})
+	}
+}
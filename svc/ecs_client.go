@@ -0,0 +1,117 @@
+package svc
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecs"
+)
+
+type ECSClient struct {
+	*ecs.ECS
+	ctx aws.Context
+}
+
+//describeClustersLimit and describeServicesLimit are the max numbers of arns per describe call
+const (
+	describeClustersLimit = 100
+	describeServicesLimit = 10
+)
+
+//FetchClusters returns the clusters sorted by name with their task counts. Services are not set.
+func (c *ECSClient) FetchClusters() ([]*Cluster, error) {
+	arns := make([]*string, 0)
+	err := c.ListClustersPagesWithContext(c.ctx, &ecs.ListClustersInput{}, func(page *ecs.ListClustersOutput, lastPage bool) bool {
+		arns = append(arns, page.ClusterArns...)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	clusters := make([]*Cluster, 0, len(arns))
+	for i := 0; i < len(arns); i += describeClustersLimit {
+		end := i + describeClustersLimit
+		if end > len(arns) {
+			end = len(arns)
+		}
+		output, err := c.DescribeClustersWithContext(c.ctx, &ecs.DescribeClustersInput{Clusters: arns[i:end]})
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range output.Clusters {
+			if v.ClusterArn == nil {
+				continue
+			}
+			clusters = append(clusters, &Cluster{
+				Name:         stringOrDash(v.ClusterName),
+				Arn:          *v.ClusterArn,
+				Status:       stringOrDash(v.Status),
+				RunningTasks: aws.Int64Value(v.RunningTasksCount),
+				PendingTasks: aws.Int64Value(v.PendingTasksCount),
+				Services:     make([]*ECSService, 0),
+			})
+		}
+	}
+	sort.Slice(clusters, func(i, j int) bool { return clusters[i].Name < clusters[j].Name })
+	return clusters, nil
+}
+
+//FetchServices returns the services of the cluster sorted by name
+func (c *ECSClient) FetchServices(cluster string) ([]*ECSService, error) {
+	arns := make([]*string, 0)
+	input := &ecs.ListServicesInput{Cluster: aws.String(cluster)}
+	err := c.ListServicesPagesWithContext(c.ctx, input, func(page *ecs.ListServicesOutput, lastPage bool) bool {
+		arns = append(arns, page.ServiceArns...)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	services := make([]*ECSService, 0, len(arns))
+	for i := 0; i < len(arns); i += describeServicesLimit {
+		end := i + describeServicesLimit
+		if end > len(arns) {
+			end = len(arns)
+		}
+		output, err := c.DescribeServicesWithContext(c.ctx, &ecs.DescribeServicesInput{
+			Cluster:  aws.String(cluster),
+			Services: arns[i:end],
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range output.Services {
+			services = append(services, parseECSService(v))
+		}
+	}
+	sort.Slice(services, func(i, j int) bool { return services[i].Name < services[j].Name })
+	return services, nil
+}
+
+func parseECSService(v *ecs.Service) *ECSService {
+	s := &ECSService{
+		Name:           stringOrDash(v.ServiceName),
+		Status:         stringOrDash(v.Status),
+		DesiredCount:   aws.Int64Value(v.DesiredCount),
+		RunningCount:   aws.Int64Value(v.RunningCount),
+		PendingCount:   aws.Int64Value(v.PendingCount),
+		LaunchType:     stringOrDash(v.LaunchType),
+		Subnets:        make([]string, 0),
+		SecurityGroups: make([]string, 0),
+	}
+	if len(v.CapacityProviderStrategy) > 0 {
+		providers := make([]string, 0, len(v.CapacityProviderStrategy))
+		for _, p := range v.CapacityProviderStrategy {
+			providers = append(providers, aws.StringValue(p.CapacityProvider))
+		}
+		s.LaunchType = strings.Join(providers, ",")
+	}
+	if v.NetworkConfiguration != nil && v.NetworkConfiguration.AwsvpcConfiguration != nil {
+		conf := v.NetworkConfiguration.AwsvpcConfiguration
+		s.Subnets = aws.StringValueSlice(conf.Subnets)
+		s.SecurityGroups = aws.StringValueSlice(conf.SecurityGroups)
+		s.AssignPublicIP = aws.StringValue(conf.AssignPublicIp) == ecs.AssignPublicIpEnabled
+	}
+	return s
+}
@@ -0,0 +1,48 @@
+package svc
+
+//Cluster is an ecs cluster with its services
+type Cluster struct {
+	Name         string        `json:"name"`
+	Arn          string        `json:"arn"`
+	Status       string        `json:"status"`
+	RunningTasks int64         `json:"runningTasks"`
+	PendingTasks int64         `json:"pendingTasks"`
+	Services     []*ECSService `json:"services"`
+}
+
+//ECSService is an ecs service with its task counts.
+//Subnets and SecurityGroups are set only in awsvpc network mode.
+type ECSService struct {
+	Name         string `json:"name"`
+	Status       string `json:"status"`
+	DesiredCount int64  `json:"desiredCount"`
+	RunningCount int64  `json:"runningCount"`
+	PendingCount int64  `json:"pendingCount"`
+	//LaunchType is EC2, FARGATE or EXTERNAL, or the capacity providers joined by comma when a strategy is used
+	LaunchType     string   `json:"launchType"`
+	Subnets        []string `json:"subnets"`
+	SecurityGroups []string `json:"securityGroups"`
+	AssignPublicIP bool     `json:"assignPublicIp"`
+}
+
+//StandaloneTasks is the number of running tasks not started by the services
+func (c *Cluster) StandaloneTasks() int64 {
+	n := c.RunningTasks
+	for _, s := range c.Services {
+		n -= s.RunningCount
+	}
+	if n < 0 {
+		return 0
+	}
+	return n
+}
+
+//IsAwsvpc reports whether the tasks of the service have their own network interfaces in Subnets
+func (s *ECSService) IsAwsvpc() bool {
+	return len(s.Subnets) > 0
+}
+
+//IsUnderDesired reports whether fewer tasks are running than desired
+func (s *ECSService) IsUnderDesired() bool {
+	return s.RunningCount < s.DesiredCount
+}
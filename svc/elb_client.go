@@ -0,0 +1,43 @@
+package svc
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/elb"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+)
+
+type ELBClient struct {
+	*elb.ELB
+	ctx aws.Context
+}
+
+func (c *ELBClient) FetchClassicLoadBalancers() (*elb.DescribeLoadBalancersOutput, error) {
+	input := &elb.DescribeLoadBalancersInput{}
+	output := &elb.DescribeLoadBalancersOutput{}
+	err := c.DescribeLoadBalancersPagesWithContext(c.ctx, input, func(page *elb.DescribeLoadBalancersOutput, lastPage bool) bool {
+		output.LoadBalancerDescriptions = append(output.LoadBalancerDescriptions, page.LoadBalancerDescriptions...)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+type ELBV2Client struct {
+	*elbv2.ELBV2
+	ctx aws.Context
+}
+
+func (c *ELBV2Client) FetchLoadBalancersV2() (*elbv2.DescribeLoadBalancersOutput, error) {
+	input := &elbv2.DescribeLoadBalancersInput{}
+	output := &elbv2.DescribeLoadBalancersOutput{}
+	err := c.DescribeLoadBalancersPagesWithContext(c.ctx, input, func(page *elbv2.DescribeLoadBalancersOutput, lastPage bool) bool {
+		output.LoadBalancers = append(output.LoadBalancers, page.LoadBalancers...)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return output, nil
+}
@@ -0,0 +1,47 @@
+package svc
+
+import (
+	"fmt"
+	"strings"
+)
+
+//AggregateError holds every error collected while building a report
+type AggregateError struct {
+	errs []error
+}
+
+//NewAggregateError returns nil when errs is empty
+func NewAggregateError(errs []error) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return &AggregateError{errs: append([]error{}, errs...)}
+}
+
+func (e *AggregateError) Error() string {
+	var b strings.Builder
+	for _, err := range e.errs {
+		b.WriteString(err.Error())
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func (e *AggregateError) Errors() []error {
+	return append([]error{}, e.errs...)
+}
+
+//Unwrap lets errors.Is and errors.As look into each error
+func (e *AggregateError) Unwrap() []error {
+	return e.errs
+}
+
+//SubnetOverlapError tells subnets of a vpc whose cidrs overlap. It is a finding of the model, not a fetch failure.
+type SubnetOverlapError struct {
+	VpcID string
+	A, B  *Subnet
+}
+
+func (e *SubnetOverlapError) Error() string {
+	return fmt.Sprintf("%s: subnet %s %s overlaps %s %s", e.VpcID, e.A.ID, e.A.CidrBlock, e.B.ID, e.B.CidrBlock)
+}
@@ -0,0 +1,30 @@
+package svc
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+)
+
+func TestAggregateErrorUnwrap(t *testing.T) {
+	throttled := awserr.New("Throttling", "Rate exceeded", nil)
+	err := NewAggregateError([]error{
+		errors.New("vpc-1: not found"),
+		fmt.Errorf("ap-northeast-1: %w", throttled),
+	})
+	if !errors.Is(err, throttled) {
+		t.Errorf("errors.Is(%v, throttled) = false, want true", err)
+	}
+	var aerr awserr.Error
+	if !errors.As(err, &aerr) {
+		t.Fatalf("errors.As(%v, awserr.Error) = false, want true", err)
+	}
+	if aerr.Code() != "Throttling" {
+		t.Errorf("got code %s, want Throttling", aerr.Code())
+	}
+	if errors.Is(err, errors.New("vpc-1: not found")) {
+		t.Error("errors.Is matched an error which is not in the aggregate")
+	}
+}
@@ -7,77 +7,162 @@ import (
 
 type IAMClient struct {
 	*iam.IAM
+	ctx aws.Context
 }
 
 func (c *IAMClient) FetchRoles() (*iam.ListRolesOutput, error) {
 	input := &iam.ListRolesInput{}
-	return c.ListRoles(input)
+	output := &iam.ListRolesOutput{}
+	err := c.ListRolesPagesWithContext(c.ctx, input, func(page *iam.ListRolesOutput, lastPage bool) bool {
+		output.Roles = append(output.Roles, page.Roles...)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return output, nil
 }
 
 func (c *IAMClient) FetchRolePolicies(name *string) (*iam.ListRolePoliciesOutput, error) {
 	input := &iam.ListRolePoliciesInput{
 		RoleName: name,
 	}
-	return c.ListRolePolicies(input)
+	output := &iam.ListRolePoliciesOutput{}
+	err := c.ListRolePoliciesPagesWithContext(c.ctx, input, func(page *iam.ListRolePoliciesOutput, lastPage bool) bool {
+		output.PolicyNames = append(output.PolicyNames, page.PolicyNames...)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return output, nil
 }
 
 func (c *IAMClient) FetchRoleManagedPolicies(name *string) (*iam.ListAttachedRolePoliciesOutput, error) {
 	input := &iam.ListAttachedRolePoliciesInput{
 		RoleName: name,
 	}
-	return c.ListAttachedRolePolicies(input)
+	output := &iam.ListAttachedRolePoliciesOutput{}
+	err := c.ListAttachedRolePoliciesPagesWithContext(c.ctx, input, func(page *iam.ListAttachedRolePoliciesOutput, lastPage bool) bool {
+		output.AttachedPolicies = append(output.AttachedPolicies, page.AttachedPolicies...)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return output, nil
 }
 
 func (c *IAMClient) FetchGroups() (*iam.ListGroupsOutput, error) {
 	input := &iam.ListGroupsInput{}
-	return c.ListGroups(input)
+	output := &iam.ListGroupsOutput{}
+	err := c.ListGroupsPagesWithContext(c.ctx, input, func(page *iam.ListGroupsOutput, lastPage bool) bool {
+		output.Groups = append(output.Groups, page.Groups...)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return output, nil
 }
 
 func (c *IAMClient) FetchGroupPolicies(name *string) (*iam.ListGroupPoliciesOutput, error) {
 	input := &iam.ListGroupPoliciesInput{
 		GroupName: name,
 	}
-	return c.ListGroupPolicies(input)
+	output := &iam.ListGroupPoliciesOutput{}
+	err := c.ListGroupPoliciesPagesWithContext(c.ctx, input, func(page *iam.ListGroupPoliciesOutput, lastPage bool) bool {
+		output.PolicyNames = append(output.PolicyNames, page.PolicyNames...)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return output, nil
 }
 
 func (c *IAMClient) FetchGroupManagedPolicies(name *string) (*iam.ListAttachedGroupPoliciesOutput, error) {
 	input := &iam.ListAttachedGroupPoliciesInput{
 		GroupName: name,
 	}
-	return c.ListAttachedGroupPolicies(input)
+	output := &iam.ListAttachedGroupPoliciesOutput{}
+	err := c.ListAttachedGroupPoliciesPagesWithContext(c.ctx, input, func(page *iam.ListAttachedGroupPoliciesOutput, lastPage bool) bool {
+		output.AttachedPolicies = append(output.AttachedPolicies, page.AttachedPolicies...)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return output, nil
 }
 
 func (c *IAMClient) FetchUsers() (*iam.ListUsersOutput, error) {
 	input := &iam.ListUsersInput{}
-	return c.ListUsers(input)
+	output := &iam.ListUsersOutput{}
+	err := c.ListUsersPagesWithContext(c.ctx, input, func(page *iam.ListUsersOutput, lastPage bool) bool {
+		output.Users = append(output.Users, page.Users...)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return output, nil
 }
 
 func (c *IAMClient) FetchUserPolicies(name *string) (*iam.ListUserPoliciesOutput, error) {
 	input := &iam.ListUserPoliciesInput{
 		UserName: name,
 	}
-	return c.ListUserPolicies(input)
+	output := &iam.ListUserPoliciesOutput{}
+	err := c.ListUserPoliciesPagesWithContext(c.ctx, input, func(page *iam.ListUserPoliciesOutput, lastPage bool) bool {
+		output.PolicyNames = append(output.PolicyNames, page.PolicyNames...)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return output, nil
 }
 
 func (c *IAMClient) FetchUserManagedPolicies(name *string) (*iam.ListAttachedUserPoliciesOutput, error) {
 	input := &iam.ListAttachedUserPoliciesInput{
 		UserName: name,
 	}
-	return c.ListAttachedUserPolicies(input)
+	output := &iam.ListAttachedUserPoliciesOutput{}
+	err := c.ListAttachedUserPoliciesPagesWithContext(c.ctx, input, func(page *iam.ListAttachedUserPoliciesOutput, lastPage bool) bool {
+		output.AttachedPolicies = append(output.AttachedPolicies, page.AttachedPolicies...)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return output, nil
 }
 
 func (c *IAMClient) FetchUserGroups(name *string) (*iam.ListGroupsForUserOutput, error) {
 	input := &iam.ListGroupsForUserInput{
 		UserName: name,
 	}
-	return c.ListGroupsForUser(input)
+	output := &iam.ListGroupsForUserOutput{}
+	err := c.ListGroupsForUserPagesWithContext(c.ctx, input, func(page *iam.ListGroupsForUserOutput, lastPage bool) bool {
+		output.Groups = append(output.Groups, page.Groups...)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return output, nil
 }
 
 func (c *IAMClient) FetchPolicies() (*iam.ListPoliciesOutput, error) {
 	input := &iam.ListPoliciesInput{
 		OnlyAttached: aws.Bool(true),
 	}
-	result, err := c.ListPolicies(input)
+	result := &iam.ListPoliciesOutput{}
+	err := c.ListPoliciesPagesWithContext(c.ctx, input, func(page *iam.ListPoliciesOutput, lastPage bool) bool {
+		result.Policies = append(result.Policies, page.Policies...)
+		return true
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -96,5 +181,18 @@ func (c *IAMClient) fetchPolicyVersion(arn, version *string) (*iam.GetPolicyVers
 		PolicyArn: arn,
 		VersionId: version,
 	}
-	return c.GetPolicyVersion(input)
+	return c.GetPolicyVersionWithContext(c.ctx, input)
+}
+
+func (c *IAMClient) FetchAccountAliases() (*iam.ListAccountAliasesOutput, error) {
+	input := &iam.ListAccountAliasesInput{}
+	output := &iam.ListAccountAliasesOutput{}
+	err := c.ListAccountAliasesPagesWithContext(c.ctx, input, func(page *iam.ListAccountAliasesOutput, lastPage bool) bool {
+		output.AccountAliases = append(output.AccountAliases, page.AccountAliases...)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return output, nil
 }
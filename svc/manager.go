@@ -1,29 +1,131 @@
 package svc
 
 import (
+	"context"
+	"fmt"
 	"os"
+	"strings"
+	"time"
 
+	"github.com/atsushi-ishibashi/aws-state-report/util"
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awsutil"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/aws/aws-sdk-go/service/elb"
+	"github.com/aws/aws-sdk-go/service/elbv2"
 	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/rds"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/sts"
 )
 
 type Manager struct {
 	*EC2Client
 	*IAMClient
 	*SGClient
+	*STSClient
+	*Route53Client
+	*ELBClient
+	*ELBV2Client
+	*S3Client
+	*RDSClient
+	*ECSClient
+	Region string
+	sess   *session.Session
+	ctx    aws.Context
 }
 
-func NewManager() (*Manager, error) {
+//DryRun makes the clients of managers created afterwards print each api call instead of sending it.
+//The calls return empty outputs.
+var DryRun bool
+
+//MaxRetries is the number of retries of throttled or failed api calls with exponential backoff
+var MaxRetries = 10
+
+//NewManager creates a manager whose api calls are canceled when ctx is done
+func NewManager(ctx context.Context) (*Manager, error) {
 	awsregion := os.Getenv("AWS_DEFAULT_REGION")
-	sess, err := session.NewSession()
+	//shared config is enabled so that the role and sso settings of AWS_PROFILE in ~/.aws/config are honored
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config: aws.Config{
+			Retryer: client.DefaultRetryer{
+				NumMaxRetries:    MaxRetries,
+				MinRetryDelay:    100 * time.Millisecond,
+				MaxRetryDelay:    5 * time.Second,
+				MinThrottleDelay: 500 * time.Millisecond,
+				MaxThrottleDelay: 20 * time.Second,
+			},
+		},
+		SharedConfigState: session.SharedConfigEnable,
+	})
 	if err != nil {
 		return nil, err
 	}
-	m := &Manager{}
-	m.EC2Client = &EC2Client{EC2: ec2.New(sess, &aws.Config{Region: aws.String(awsregion)})}
-	m.IAMClient = &IAMClient{IAM: iam.New(sess, &aws.Config{Region: aws.String(awsregion)})}
-	m.SGClient = &SGClient{EC2: ec2.New(sess, &aws.Config{Region: aws.String(awsregion)})}
-	return m, nil
+	if DryRun {
+		sess.Handlers.Validate.PushBackNamed(dryRunHandler)
+	}
+	if FromCache {
+		sess.Handlers.Validate.PushBackNamed(cacheReadHandler)
+	} else if CacheDir != "" {
+		sess.Handlers.Complete.PushBackNamed(cacheWriteHandler)
+	}
+	if util.Verbose {
+		sess.Handlers.Complete.PushBackNamed(verboseHandler)
+	}
+	return newManager(ctx, sess, awsregion), nil
+}
+
+var verboseHandler = request.NamedHandler{
+	Name: "awsstatereport.VerboseHandler",
+	Fn: func(r *request.Request) {
+		//the error itself is logged once by stackError of the command
+		result := "ok"
+		if r.Error != nil {
+			result = "failed"
+		}
+		util.Debugf("called %s:%s (%s) %s retries=%d (%s)", r.ClientInfo.ServiceName, r.Operation.Name, aws.StringValue(r.Config.Region), result, r.RetryCount, time.Since(r.Time))
+	},
+}
+
+var dryRunHandler = request.NamedHandler{
+	Name: "awsstatereport.DryRunHandler",
+	Fn: func(r *request.Request) {
+		params := strings.Join(strings.Fields(awsutil.Prettify(r.Params)), " ")
+		fmt.Printf("[dry-run] %s:%s (%s) %s\n", r.ClientInfo.ServiceName, r.Operation.Name, aws.StringValue(r.Config.Region), params)
+		r.Handlers.Sign.Clear()
+		r.Handlers.Send.Clear()
+		r.Handlers.UnmarshalMeta.Clear()
+		r.Handlers.ValidateResponse.Clear()
+		r.Handlers.Unmarshal.Clear()
+	},
+}
+
+//WithRegion returns a new Manager sharing the session whose clients call the given region
+func (m *Manager) WithRegion(region string) *Manager {
+	return newManager(m.ctx, m.sess, region)
+}
+
+//WithContext returns a new Manager sharing the session whose api calls are canceled when ctx is done
+func (m *Manager) WithContext(ctx context.Context) *Manager {
+	return newManager(ctx, m.sess, m.Region)
+}
+
+func newManager(ctx aws.Context, sess *session.Session, awsregion string) *Manager {
+	m := &Manager{Region: awsregion, sess: sess, ctx: ctx}
+	m.EC2Client = &EC2Client{EC2API: ec2.New(sess, &aws.Config{Region: aws.String(awsregion)}), ctx: ctx}
+	m.IAMClient = &IAMClient{IAM: iam.New(sess, &aws.Config{Region: aws.String(awsregion)}), ctx: ctx}
+	m.SGClient = &SGClient{EC2API: ec2.New(sess, &aws.Config{Region: aws.String(awsregion)}), ctx: ctx}
+	m.STSClient = &STSClient{STS: sts.New(sess, &aws.Config{Region: aws.String(awsregion)}), ctx: ctx}
+	m.Route53Client = &Route53Client{Route53: route53.New(sess, &aws.Config{Region: aws.String(awsregion)}), ctx: ctx}
+	m.ELBClient = &ELBClient{ELB: elb.New(sess, &aws.Config{Region: aws.String(awsregion)}), ctx: ctx}
+	m.ELBV2Client = &ELBV2Client{ELBV2: elbv2.New(sess, &aws.Config{Region: aws.String(awsregion)}), ctx: ctx}
+	m.RDSClient = &RDSClient{RDS: rds.New(sess, &aws.Config{Region: aws.String(awsregion)}), ctx: ctx}
+	m.ECSClient = &ECSClient{ECS: ecs.New(sess, &aws.Config{Region: aws.String(awsregion)}), ctx: ctx}
+	m.S3Client = &S3Client{S3: s3.New(sess, &aws.Config{Region: aws.String(awsregion)}), sess: sess, ctx: ctx}
+	return m
 }
@@ -0,0 +1,1209 @@
+package svc
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/atsushi-ishibashi/aws-state-report/util"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+//Concurrency is the number of vpcs or regions fetched at once, kept low to respect ec2 rate limits
+var Concurrency = 5
+
+//NetworkOptions narrows down the vpcs and subnets BuildNetworkModel fetches
+type NetworkOptions struct {
+	//TagFilters are ANDed. A vpc is kept when it matches them itself or has a matching subnet.
+	TagFilters []*ec2.Filter
+	//VpcIDs restricts the vpcs to fetch. All vpcs are fetched when it is empty.
+	VpcIDs []string
+	//ExcludeDefaultVpc drops the default vpc
+	ExcludeDefaultVpc bool
+	//IncludeManagedPrefixLists resolves the names and entries of the prefix lists routes are destined to
+	IncludeManagedPrefixLists bool
+	//IncludeNetworkInterfaces fetches the network interfaces of every subnet
+	IncludeNetworkInterfaces bool
+	//IncludeInstanceCounts counts the ec2 instances not terminated in every subnet
+	IncludeInstanceCounts bool
+	//Progress is where the numbers of vpcs done are printed while fetching. Nothing is printed when it is nil.
+	Progress io.Writer
+	//ProgressLines prints only the final count of the progress, e.g. while regions are fetched concurrently
+	ProgressLines bool
+	//ShowTags are the keys of the tags kept in Tags of the vpcs and subnets
+	ShowTags []string
+	//Skip* leave the resources unfetched when they are not reported
+	SkipNetworkAcls  bool
+	SkipVpcEndpoints bool
+	SkipFlowLogs     bool
+	SkipDhcpOptions  bool
+}
+
+type networkBuilder struct {
+	manager    *Manager
+	options    NetworkOptions
+	vpcs       []*Vpc
+	taggedVpcs map[string]bool
+	errs       []error
+	mu         sync.Mutex
+}
+
+//BuildNetworkModel fetches vpcs with their route tables, subnets and network acls,
+//and links them to each other. Fetch errors are aggregated into the returned error
+//and the vpcs fetched successfully are returned anyway.
+func BuildNetworkModel(mng *Manager, options NetworkOptions) ([]*Vpc, error) {
+	b := &networkBuilder{manager: mng, options: options}
+	b.constructVpcs().
+		constructSubnets().
+		checkOverlappingSubnets().
+		filterVpcsByTag().
+		selectTags().
+		resolveDhcpOptions().
+		resolveFlowLogs().
+		constructRouteTables().
+		constructNetworkAcls().
+		constructVpcEndpoints().
+		constructNetworkInterfaces().
+		countInstances().
+		associateRouteTableSubnet().
+		associateNetworkAclSubnet().
+		constructTransitGatewayAttachments().
+		resolveRouters().
+		resolvePrefixLists().
+		sortResources().
+		checkAvailabilityZones()
+	return b.vpcs, b.flattenErrs()
+}
+
+func (b *networkBuilder) constructVpcs() *networkBuilder {
+	start := time.Now()
+	var result *ec2.DescribeVpcsOutput
+	var err error
+	if len(b.options.VpcIDs) > 0 {
+		result, err = b.manager.FetchVpcsWithIds(b.options.VpcIDs)
+	} else {
+		result, err = b.manager.FetchVpcs()
+	}
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == "InvalidVpcID.NotFound" {
+		return b.stackError(fmt.Errorf("vpc not found: %s", aerr.Message()))
+	}
+	if err != nil {
+		return b.stackError(err)
+	}
+	vpcs, warns := ParseDescribeVpcsOutputToVpcs(result)
+	b.stackErrors(warns)
+	util.Debugf("fetched %d vpcs in %s (%s)", len(vpcs), b.manager.Region, time.Since(start))
+	b.vpcs = make([]*Vpc, 0, len(vpcs))
+	for _, v := range vpcs {
+		if b.options.ExcludeDefaultVpc && v.IsDefault {
+			continue
+		}
+		v.Region = b.manager.Region
+		b.vpcs = append(b.vpcs, v)
+	}
+	if len(b.options.TagFilters) == 0 {
+		return b
+	}
+	tagged, err := b.manager.FetchVpcs(b.options.TagFilters...)
+	if err != nil {
+		return b.stackError(err)
+	}
+	b.taggedVpcs = make(map[string]bool)
+	for _, v := range tagged.Vpcs {
+		if v.VpcId != nil {
+			b.taggedVpcs[*v.VpcId] = true
+		}
+	}
+	return b
+}
+
+//filterVpcsByTag drops vpcs which neither match the tag filters nor have a matching subnet
+func (b *networkBuilder) filterVpcsByTag() *networkBuilder {
+	if len(b.options.TagFilters) == 0 {
+		return b
+	}
+	vpcs := make([]*Vpc, 0)
+	for _, v := range b.vpcs {
+		if b.taggedVpcs[v.ID] || len(v.Subnets) > 0 {
+			vpcs = append(vpcs, v)
+		}
+	}
+	b.vpcs = vpcs
+	return b
+}
+
+//selectTags keeps only the tags of ShowTags in the vpcs and subnets
+func (b *networkBuilder) selectTags() *networkBuilder {
+	selectTags := func(tags map[string]string) map[string]string {
+		selected := make(map[string]string)
+		for _, k := range b.options.ShowTags {
+			if v, ok := tags[k]; ok {
+				selected[k] = v
+			}
+		}
+		if len(selected) == 0 {
+			return nil
+		}
+		return selected
+	}
+	for _, vpc := range b.vpcs {
+		vpc.Tags = selectTags(vpc.Tags)
+		for _, sn := range vpc.Subnets {
+			sn.Tags = selectTags(sn.Tags)
+		}
+	}
+	return b
+}
+
+//resolveDhcpOptions sets the dhcp options set to the vpcs. A vpc without one has the id "default".
+func (b *networkBuilder) resolveDhcpOptions() *networkBuilder {
+	vpcs := make(map[string][]*Vpc)
+	for _, vpc := range b.vpcs {
+		if vpc.DhcpOptionsID != "" && vpc.DhcpOptionsID != "default" {
+			vpcs[vpc.DhcpOptionsID] = append(vpcs[vpc.DhcpOptionsID], vpc)
+		}
+	}
+	if len(vpcs) == 0 || b.options.SkipDhcpOptions {
+		return b
+	}
+	result, err := b.manager.FetchDhcpOptions()
+	if err != nil {
+		return b.stackError(err)
+	}
+	for _, v := range result.DhcpOptions {
+		if v.DhcpOptionsId == nil {
+			continue
+		}
+		opts := &DhcpOptions{
+			ID:                *v.DhcpOptionsId,
+			TagName:           extractTagName(v.Tags),
+			DomainNameServers: make([]string, 0),
+			NtpServers:        make([]string, 0),
+		}
+		for _, c := range v.DhcpConfigurations {
+			values := make([]string, 0, len(c.Values))
+			for _, av := range c.Values {
+				if av.Value != nil {
+					values = append(values, *av.Value)
+				}
+			}
+			switch aws.StringValue(c.Key) {
+			case "domain-name":
+				opts.DomainName = strings.Join(values, " ")
+			case "domain-name-servers":
+				opts.DomainNameServers = values
+			case "ntp-servers":
+				opts.NtpServers = values
+			}
+		}
+		for _, vpc := range vpcs[opts.ID] {
+			vpc.DhcpOptions = opts
+		}
+	}
+	return b
+}
+
+//resolveFlowLogs sets the flow logs of the vpcs themselves. Those of subnets and network interfaces are ignored.
+func (b *networkBuilder) resolveFlowLogs() *networkBuilder {
+	if len(b.vpcs) == 0 || b.options.SkipFlowLogs {
+		return b
+	}
+	result, err := b.manager.FetchFlowLogs()
+	if err != nil {
+		return b.stackError(err)
+	}
+	flowLogs := make(map[string][]*FlowLog)
+	for _, v := range result.FlowLogs {
+		if v.FlowLogId == nil || v.ResourceId == nil {
+			continue
+		}
+		fl := &FlowLog{
+			ID:              *v.FlowLogId,
+			DestinationType: stringOrDash(v.LogDestinationType),
+			TrafficType:     stringOrDash(v.TrafficType),
+			Status:          stringOrDash(v.FlowLogStatus),
+		}
+		if v.LogDestination != nil {
+			fl.Destination = *v.LogDestination
+		} else if v.LogGroupName != nil {
+			fl.Destination = *v.LogGroupName
+		}
+		flowLogs[*v.ResourceId] = append(flowLogs[*v.ResourceId], fl)
+	}
+	for _, vpc := range b.vpcs {
+		vpc.FlowLogs = flowLogs[vpc.ID]
+		if vpc.FlowLogs == nil {
+			vpc.FlowLogs = make([]*FlowLog, 0)
+		}
+	}
+	return b
+}
+
+func (b *networkBuilder) constructRouteTables() *networkBuilder {
+	b.eachVpcWithProgress("route tables", func(vpc *Vpc) {
+		start := time.Now()
+		if result, err := b.manager.FetchRouteTablesWithVpc(vpc.ID); err != nil {
+			b.stackError(fmt.Errorf("%s: %w", vpc.ID, err))
+		} else {
+			parsed, warns := parseDescribeRouteTablesOutputToRouteTables(result)
+			vpc.RouteTables = parsed
+			b.stackErrors(warns)
+			util.Debugf("fetched %d route tables in %s %s (%s)", len(parsed), b.manager.Region, vpc.ID, time.Since(start))
+		}
+	})
+	return b
+}
+
+func (b *networkBuilder) constructSubnets() *networkBuilder {
+	b.eachVpcWithProgress("subnets", func(vpc *Vpc) {
+		start := time.Now()
+		if result, err := b.manager.FetchSubnetsWithVpc(vpc.ID, b.options.TagFilters...); err != nil {
+			b.stackError(fmt.Errorf("%s: %w", vpc.ID, err))
+		} else {
+			parsed, warns := parseDescribeSubnetsOutputToSubnets(result)
+			vpc.Subnets = parsed
+			b.stackErrors(warns)
+			util.Debugf("fetched %d subnets in %s %s (%s)", len(parsed), b.manager.Region, vpc.ID, time.Since(start))
+		}
+	})
+	return b
+}
+
+func (b *networkBuilder) constructNetworkAcls() *networkBuilder {
+	if b.options.SkipNetworkAcls {
+		return b
+	}
+	b.eachVpc(func(vpc *Vpc) {
+		start := time.Now()
+		if result, err := b.manager.FetchNetworkAclsWithVpc(vpc.ID); err != nil {
+			b.stackError(fmt.Errorf("%s: %w", vpc.ID, err))
+		} else {
+			parsed, warns := parseDescribeNetworkAclsOutputToNetworkAcls(result)
+			vpc.NetworkAcls = parsed
+			b.stackErrors(warns)
+			util.Debugf("fetched %d network acls in %s %s (%s)", len(parsed), b.manager.Region, vpc.ID, time.Since(start))
+		}
+	})
+	return b
+}
+
+func (b *networkBuilder) constructVpcEndpoints() *networkBuilder {
+	if b.options.SkipVpcEndpoints {
+		return b
+	}
+	b.eachVpc(func(vpc *Vpc) {
+		start := time.Now()
+		if result, err := b.manager.FetchVpcEndpointsWithVpc(vpc.ID); err != nil {
+			b.stackError(fmt.Errorf("%s: %w", vpc.ID, err))
+		} else {
+			parsed, warns := parseDescribeVpcEndpointsOutputToVpcEndpoints(result)
+			vpc.VpcEndpoints = parsed
+			b.stackErrors(warns)
+			util.Debugf("fetched %d vpc endpoints in %s %s (%s)", len(parsed), b.manager.Region, vpc.ID, time.Since(start))
+		}
+	})
+	return b
+}
+
+//constructNetworkInterfaces sets the network interfaces to the subnets they are in
+func (b *networkBuilder) constructNetworkInterfaces() *networkBuilder {
+	if !b.options.IncludeNetworkInterfaces {
+		return b
+	}
+	b.eachVpc(func(vpc *Vpc) {
+		start := time.Now()
+		result, err := b.manager.FetchNetworkInterfacesWithVpc(vpc.ID)
+		if err != nil {
+			b.stackError(fmt.Errorf("%s: %w", vpc.ID, err))
+			return
+		}
+		parsed, warns := parseDescribeNetworkInterfacesOutputToEnis(result)
+		b.stackErrors(warns)
+		enis := make(map[string][]*Eni)
+		for _, eni := range parsed {
+			enis[eni.SubnetID] = append(enis[eni.SubnetID], eni)
+		}
+		for _, sn := range vpc.Subnets {
+			sn.Enis = enis[sn.ID]
+			if sn.Enis == nil {
+				sn.Enis = make([]*Eni, 0)
+			}
+		}
+		util.Debugf("fetched %d network interfaces in %s %s (%s)", len(parsed), b.manager.Region, vpc.ID, time.Since(start))
+	})
+	return b
+}
+
+//countInstances sets the number of ec2 instances not terminated to the subnets they are in
+func (b *networkBuilder) countInstances() *networkBuilder {
+	if !b.options.IncludeInstanceCounts {
+		return b
+	}
+	b.eachVpc(func(vpc *Vpc) {
+		start := time.Now()
+		result, err := b.manager.FetchInstances(
+			&ec2.Filter{
+				Name:   aws.String("vpc-id"),
+				Values: []*string{aws.String(vpc.ID)},
+			},
+			&ec2.Filter{
+				Name:   aws.String("instance-state-name"),
+				Values: aws.StringSlice([]string{"pending", "running", "shutting-down", "stopping", "stopped"}),
+			},
+		)
+		if err != nil {
+			b.stackError(fmt.Errorf("%s: %w", vpc.ID, err))
+			return
+		}
+		counts := make(map[string]int)
+		total := 0
+		for _, r := range result.Reservations {
+			for _, i := range r.Instances {
+				counts[aws.StringValue(i.SubnetId)]++
+				total++
+			}
+		}
+		for _, sn := range vpc.Subnets {
+			count := counts[sn.ID]
+			sn.InstanceCount = &count
+		}
+		util.Debugf("fetched %d instances in %s %s (%s)", total, b.manager.Region, vpc.ID, time.Since(start))
+	})
+	return b
+}
+
+//eachVpcWithProgress is eachVpc printing the number of vpcs done to NetworkOptions.Progress
+func (b *networkBuilder) eachVpcWithProgress(label string, f func(vpc *Vpc)) {
+	if b.options.Progress == nil {
+		b.eachVpc(f)
+		return
+	}
+	newProgress := util.NewProgress
+	if b.options.ProgressLines {
+		newProgress = util.NewLineProgress
+	}
+	p := newProgress(b.options.Progress, fmt.Sprintf("%s (%s)", label, b.manager.Region), len(b.vpcs))
+	b.eachVpc(func(vpc *Vpc) {
+		f(vpc)
+		p.Increment()
+	})
+	p.Finish()
+}
+
+//eachVpc calls f for every vpc concurrently, at most Concurrency at a time
+func (b *networkBuilder) eachVpc(f func(vpc *Vpc)) {
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, Concurrency)
+	for _, vpc := range b.vpcs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(vpc *Vpc) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			f(vpc)
+		}(vpc)
+	}
+	wg.Wait()
+}
+
+func (b *networkBuilder) associateRouteTableSubnet() *networkBuilder {
+	for _, vpc := range b.vpcs {
+		main := vpc.MainRouteTable()
+		for _, sn := range vpc.Subnets {
+			for _, rt := range vpc.RouteTables {
+				for _, rtas := range rt.AssociationSubnets {
+					if rtas == sn.ID {
+						sn.AssociatedRouteTable = rt
+					}
+				}
+			}
+			sn.EffectiveRouteTable = sn.AssociatedRouteTable
+			if sn.EffectiveRouteTable == nil {
+				sn.EffectiveRouteTable = main
+			}
+		}
+	}
+	return b
+}
+
+func (b *networkBuilder) associateNetworkAclSubnet() *networkBuilder {
+	for _, vpc := range b.vpcs {
+		for _, sn := range vpc.Subnets {
+			for _, acl := range vpc.NetworkAcls {
+				for _, as := range acl.AssociationSubnets {
+					if as == sn.ID {
+						sn.AssociatedNetworkAcl = acl
+					}
+				}
+			}
+		}
+	}
+	return b
+}
+
+//resolveRouters names the routers of the routes. Transit gateways are named by the attachments of constructTransitGatewayAttachments.
+func (b *networkBuilder) resolveRouters() *networkBuilder {
+	labels := make(map[string]string)
+	if result, err := b.manager.FetchInternetGateways(); err != nil {
+		b.stackError(err)
+	} else {
+		for _, v := range result.InternetGateways {
+			labels[*v.InternetGatewayId] = gatewayLabel(extractTagName(v.Tags), *v.InternetGatewayId)
+		}
+	}
+	subnetNames := make(map[string]string)
+	for _, vpc := range b.vpcs {
+		for _, sn := range vpc.Subnets {
+			subnetNames[sn.ID] = gatewayLabel(sn.TagName, sn.ID)
+		}
+	}
+	if result, err := b.manager.FetchNatGateways(); err != nil {
+		b.stackError(err)
+	} else {
+		for _, v := range result.NatGateways {
+			label := gatewayLabel(extractTagName(v.Tags), *v.NatGatewayId)
+			if v.SubnetId != nil {
+				sn, ok := subnetNames[*v.SubnetId]
+				if !ok {
+					sn = *v.SubnetId
+				}
+				label = fmt.Sprintf("%s (%s)", label, sn)
+			}
+			labels[*v.NatGatewayId] = label
+		}
+	}
+	if b.routesTo("vgw-") {
+		if result, err := b.manager.FetchVpnGateways(); err != nil {
+			b.stackError(err)
+		} else {
+			for _, v := range result.VpnGateways {
+				labels[*v.VpnGatewayId] = gatewayLabel(extractTagName(v.Tags), *v.VpnGatewayId)
+			}
+		}
+	}
+	if result, err := b.manager.FetchVpcPeeringConnections(); err != nil {
+		b.stackError(err)
+	} else {
+		vpcNames := make(map[string]string)
+		for _, vpc := range b.vpcs {
+			vpcNames[vpc.ID] = gatewayLabel(vpc.TagName, vpc.ID)
+		}
+		vpcs := make(map[string]*Vpc)
+		for _, vpc := range b.vpcs {
+			vpc.PeeringConnections = make([]*PeeringConnection, 0)
+			vpcs[vpc.ID] = vpc
+		}
+		for _, v := range result.VpcPeeringConnections {
+			labels[*v.VpcPeeringConnectionId] = fmt.Sprintf("%s (%s <-> %s)",
+				*v.VpcPeeringConnectionId, peerVpcLabel(v.RequesterVpcInfo, vpcNames), peerVpcLabel(v.AccepterVpcInfo, vpcNames))
+			pcx := &PeeringConnection{
+				ID:        *v.VpcPeeringConnectionId,
+				TagName:   extractTagName(v.Tags),
+				Requester: parsePeeringVpc(v.RequesterVpcInfo),
+				Accepter:  parsePeeringVpc(v.AccepterVpcInfo),
+				Status:    "-",
+			}
+			if v.Status != nil && v.Status.Code != nil {
+				pcx.Status = *v.Status.Code
+			}
+			for _, id := range []string{pcx.Requester.VpcID, pcx.Accepter.VpcID} {
+				if vpc, ok := vpcs[id]; ok {
+					vpc.PeeringConnections = append(vpc.PeeringConnections, pcx)
+				}
+			}
+		}
+	}
+	for _, vpc := range b.vpcs {
+		tgwLabels := make(map[string]string)
+		for _, a := range vpc.TransitGatewayAttachments {
+			tgwLabels[a.TransitGatewayID] = fmt.Sprintf("%s (%s attachment %s)",
+				gatewayLabel(a.TransitGatewayName, a.TransitGatewayID), a.ResourceType, gatewayLabel(a.TagName, a.ID))
+		}
+		for _, rt := range vpc.RouteTables {
+			for _, r := range rt.Routes {
+				if label, ok := labels[r.Router]; ok {
+					r.RouterName = label
+				}
+				if label, ok := tgwLabels[r.Router]; ok {
+					r.RouterName = label
+				}
+			}
+		}
+	}
+	return b
+}
+
+//routesTo reports whether any route is destined to a router whose id has the prefix
+func (b *networkBuilder) routesTo(prefix string) bool {
+	for _, vpc := range b.vpcs {
+		for _, rt := range vpc.RouteTables {
+			for _, r := range rt.Routes {
+				if strings.HasPrefix(r.Router, prefix) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+//constructTransitGatewayAttachments sets the attachments of each vpc with the names of their transit gateways
+func (b *networkBuilder) constructTransitGatewayAttachments() *networkBuilder {
+	tgwNames := make(map[string]string)
+	if result, err := b.manager.FetchTransitGateways(); err != nil {
+		return b.stackError(err)
+	} else {
+		for _, v := range result.TransitGateways {
+			if v.TransitGatewayId != nil {
+				tgwNames[*v.TransitGatewayId] = extractTagName(v.Tags)
+			}
+		}
+	}
+	result, err := b.manager.FetchTransitGatewayAttachments()
+	if err != nil {
+		return b.stackError(err)
+	}
+	vpcs := make(map[string]*Vpc)
+	for _, vpc := range b.vpcs {
+		vpc.TransitGatewayAttachments = make([]*TransitGatewayAttachment, 0)
+		vpcs[vpc.ID] = vpc
+	}
+	for _, v := range result.TransitGatewayAttachments {
+		if v.TransitGatewayAttachmentId == nil || v.TransitGatewayId == nil || v.ResourceId == nil {
+			continue
+		}
+		vpc, ok := vpcs[*v.ResourceId]
+		if !ok {
+			continue
+		}
+		vpc.TransitGatewayAttachments = append(vpc.TransitGatewayAttachments, &TransitGatewayAttachment{
+			ID:                 *v.TransitGatewayAttachmentId,
+			TagName:            extractTagName(v.Tags),
+			TransitGatewayID:   *v.TransitGatewayId,
+			TransitGatewayName: tgwNames[*v.TransitGatewayId],
+			ResourceType:       stringOrDash(v.ResourceType),
+			State:              stringOrDash(v.State),
+		})
+	}
+	return b
+}
+
+func (b *networkBuilder) resolvePrefixLists() *networkBuilder {
+	if !b.options.IncludeManagedPrefixLists {
+		return b
+	}
+	routes := make(map[string][]*Route)
+	for _, vpc := range b.vpcs {
+		for _, rt := range vpc.RouteTables {
+			for _, r := range rt.Routes {
+				if r.DestinationPrefixListID != "" {
+					routes[r.DestinationPrefixListID] = append(routes[r.DestinationPrefixListID], r)
+				}
+			}
+		}
+	}
+	if len(routes) == 0 {
+		return b
+	}
+	result, err := b.manager.FetchManagedPrefixLists()
+	if err != nil {
+		return b.stackError(err)
+	}
+	for _, pl := range result.PrefixLists {
+		if pl.PrefixListId == nil {
+			continue
+		}
+		rs, ok := routes[*pl.PrefixListId]
+		if !ok {
+			continue
+		}
+		entries := make([]string, 0)
+		if out, err := b.manager.FetchManagedPrefixListEntries(*pl.PrefixListId); err != nil {
+			b.stackError(fmt.Errorf("%s: %w", *pl.PrefixListId, err))
+		} else {
+			for _, e := range out.Entries {
+				if e.Cidr != nil {
+					entries = append(entries, *e.Cidr)
+				}
+			}
+		}
+		for _, r := range rs {
+			if pl.PrefixListName != nil {
+				r.DestinationPrefixListName = *pl.PrefixListName
+			}
+			r.DestinationPrefixListEntries = entries
+		}
+	}
+	return b
+}
+
+//sortResources makes the output independent of api response order.
+//Vpcs and route tables are sorted by tag name then id, and subnets by availability zone then cidr.
+//The association subnets of route tables follow the order of the subnets.
+func (b *networkBuilder) sortResources() *networkBuilder {
+	sort.SliceStable(b.vpcs, func(i, j int) bool {
+		return lessByName(b.vpcs[i].TagName, b.vpcs[i].ID, b.vpcs[j].TagName, b.vpcs[j].ID)
+	})
+	for _, vpc := range b.vpcs {
+		rts := vpc.RouteTables
+		sort.SliceStable(rts, func(i, j int) bool {
+			return lessByName(rts[i].TagName, rts[i].ID, rts[j].TagName, rts[j].ID)
+		})
+		sns := vpc.Subnets
+		sort.SliceStable(sns, func(i, j int) bool {
+			if sns[i].AvailabilityZone != sns[j].AvailabilityZone {
+				return sns[i].AvailabilityZone < sns[j].AvailabilityZone
+			}
+			return lessCidr(sns[i].CidrBlock, sns[j].CidrBlock)
+		})
+		order := make(map[string]int)
+		for i, sn := range sns {
+			order[sn.ID] = i
+		}
+		for _, rt := range rts {
+			sortSubnetIDs(rt.AssociationSubnets, order)
+		}
+	}
+	return b
+}
+
+//sortSubnetIDs sorts the ids in the order of the subnets. Those not found, e.g. implicit, go last.
+func sortSubnetIDs(ids []string, order map[string]int) {
+	sort.SliceStable(ids, func(i, j int) bool {
+		oi, iok := order[ids[i]]
+		oj, jok := order[ids[j]]
+		if iok != jok {
+			return iok
+		}
+		return oi < oj
+	})
+}
+
+func lessByName(nameA, idA, nameB, idB string) bool {
+	if nameA != nameB {
+		return nameA < nameB
+	}
+	return idA < idB
+}
+
+//checkOverlappingSubnets records the subnets whose ipv4 cidrs overlap within each vpc as errors
+func (b *networkBuilder) checkOverlappingSubnets() *networkBuilder {
+	for _, vpc := range b.vpcs {
+		for i, sa := range vpc.Subnets {
+			_, netA, err := net.ParseCIDR(sa.CidrBlock)
+			if err != nil {
+				continue
+			}
+			for _, sb := range vpc.Subnets[i+1:] {
+				_, netB, err := net.ParseCIDR(sb.CidrBlock)
+				if err != nil {
+					continue
+				}
+				if !netA.Contains(netB.IP) && !netB.Contains(netA.IP) {
+					continue
+				}
+				sa.OverlappingSubnets = append(sa.OverlappingSubnets, sb.ID)
+				sb.OverlappingSubnets = append(sb.OverlappingSubnets, sa.ID)
+				b.stackError(&SubnetOverlapError{VpcID: vpc.ID, A: sa, B: sb})
+			}
+		}
+	}
+	return b
+}
+
+//lessCidr compares cidrs by address then prefix length, and falls back to string comparison
+func lessCidr(a, b string) bool {
+	ipA, netA, errA := net.ParseCIDR(a)
+	ipB, netB, errB := net.ParseCIDR(b)
+	if errA != nil || errB != nil {
+		return a < b
+	}
+	if c := bytes.Compare(ipA.To16(), ipB.To16()); c != 0 {
+		return c < 0
+	}
+	onesA, _ := netA.Mask.Size()
+	onesB, _ := netB.Mask.Size()
+	return onesA < onesB
+}
+
+//checkAvailabilityZones warns vpcs whose subnets are in a single availability zone.
+//The warnings are not errors so that they neither fail the command nor make the report partial.
+func (b *networkBuilder) checkAvailabilityZones() *networkBuilder {
+	for _, vpc := range b.vpcs {
+		azs := make(map[string]bool)
+		for _, sn := range vpc.Subnets {
+			azs[sn.AvailabilityZone] = true
+		}
+		if len(vpc.Subnets) > 0 && len(azs) < 2 {
+			vpc.HaWarnings = append(vpc.HaWarnings, fmt.Sprintf("subnets are only in %s", vpc.Subnets[0].AvailabilityZone))
+		}
+	}
+	return b
+}
+
+//peerVpcLabel falls back to cidr and account id when the vpc is not visible, e.g. in another account
+func peerVpcLabel(info *ec2.VpcPeeringConnectionVpcInfo, vpcNames map[string]string) string {
+	if info == nil {
+		return "-"
+	}
+	if info.VpcId != nil {
+		if name, ok := vpcNames[*info.VpcId]; ok {
+			return name
+		}
+	}
+	var cidr, owner string
+	if info.CidrBlock != nil {
+		cidr = *info.CidrBlock
+	}
+	if info.OwnerId != nil {
+		owner = *info.OwnerId
+	}
+	return fmt.Sprintf("%s account:%s", cidr, owner)
+}
+
+func parsePeeringVpc(info *ec2.VpcPeeringConnectionVpcInfo) *PeeringVpc {
+	pv := &PeeringVpc{}
+	if info == nil {
+		return pv
+	}
+	pv.VpcID = aws.StringValue(info.VpcId)
+	pv.CidrBlock = aws.StringValue(info.CidrBlock)
+	pv.OwnerID = aws.StringValue(info.OwnerId)
+	pv.Region = aws.StringValue(info.Region)
+	return pv
+}
+
+func gatewayLabel(tagName, id string) string {
+	if tagName == "" {
+		return id
+	}
+	return fmt.Sprintf("%s %s", tagName, id)
+}
+
+func (b *networkBuilder) stackError(err error) *networkBuilder {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	util.LogError(fmt.Errorf("%s: %s", b.manager.Region, err))
+	b.errs = append(b.errs, err)
+	return b
+}
+
+func (b *networkBuilder) stackErrors(errs []error) *networkBuilder {
+	for _, err := range errs {
+		b.stackError(err)
+	}
+	return b
+}
+
+func (b *networkBuilder) flattenErrs() error {
+	return NewAggregateError(b.errs)
+}
+
+//ParseDescribeVpcsOutputToVpcs skips vpcs without id and returns a warning for each of them
+func ParseDescribeVpcsOutputToVpcs(output *ec2.DescribeVpcsOutput) ([]*Vpc, []error) {
+	vs := make([]*Vpc, 0)
+	warns := make([]error, 0)
+	for _, v := range output.Vpcs {
+		if v.VpcId == nil {
+			warns = append(warns, fmt.Errorf("skipped a vpc without VpcId"))
+			continue
+		}
+		vpc := &Vpc{
+			ID:        *v.VpcId,
+			TagName:   extractTagName(v.Tags),
+			CidrBlock: stringOrDash(v.CidrBlock),
+			Tags:      extractTags(v.Tags),
+		}
+		if v.IsDefault != nil {
+			vpc.IsDefault = *v.IsDefault
+		}
+		if v.DhcpOptionsId != nil {
+			vpc.DhcpOptionsID = *v.DhcpOptionsId
+		}
+		acbs := make([]string, 0)
+		for _, cbs := range v.CidrBlockAssociationSet {
+			if cbs.CidrBlock != nil {
+				acbs = append(acbs, *cbs.CidrBlock)
+			}
+		}
+		vpc.AssociatedCidrBlocks = acbs
+		vpc.Ipv6CidrBlocks = make([]string, 0)
+		for _, cbs := range v.Ipv6CidrBlockAssociationSet {
+			if cbs.Ipv6CidrBlock != nil {
+				vpc.Ipv6CidrBlocks = append(vpc.Ipv6CidrBlocks, *cbs.Ipv6CidrBlock)
+			}
+		}
+		vs = append(vs, vpc)
+	}
+	return vs, warns
+}
+
+func parseDescribeRouteTablesOutputToRouteTables(output *ec2.DescribeRouteTablesOutput) ([]*RouteTable, []error) {
+	rts := make([]*RouteTable, 0)
+	warns := make([]error, 0)
+	for _, v := range output.RouteTables {
+		if v.RouteTableId == nil {
+			warns = append(warns, fmt.Errorf("skipped a route table without RouteTableId"))
+			continue
+		}
+		rt := &RouteTable{
+			ID:      *v.RouteTableId,
+			TagName: extractTagName(v.Tags),
+		}
+		rs := make([]*Route, 0)
+		for _, r := range v.Routes {
+			rr := &Route{}
+			switch {
+			case r.DestinationCidrBlock != nil:
+				rr.DestinationCidrBlock = *r.DestinationCidrBlock
+			case r.DestinationIpv6CidrBlock != nil:
+				rr.DestinationIpv6CidrBlock = *r.DestinationIpv6CidrBlock
+			case r.DestinationPrefixListId != nil:
+				rr.DestinationPrefixListID = *r.DestinationPrefixListId
+			default:
+				warns = append(warns, fmt.Errorf("%s: skipped a route without destination", *v.RouteTableId))
+				continue
+			}
+			var routerID string
+			if r.InstanceId != nil {
+				routerID = *r.InstanceId
+			} else if r.NetworkInterfaceId != nil {
+				routerID = *r.NetworkInterfaceId
+			}
+			if r.GatewayId != nil {
+				routerID = *r.GatewayId
+			}
+			if r.LocalGatewayId != nil {
+				routerID = *r.LocalGatewayId
+			}
+			if r.CarrierGatewayId != nil {
+				routerID = *r.CarrierGatewayId
+			}
+			if r.EgressOnlyInternetGatewayId != nil {
+				routerID = *r.EgressOnlyInternetGatewayId
+			}
+			if r.NatGatewayId != nil {
+				routerID = *r.NatGatewayId
+			}
+			if r.VpcPeeringConnectionId != nil {
+				routerID = *r.VpcPeeringConnectionId
+			}
+			if r.TransitGatewayId != nil {
+				routerID = *r.TransitGatewayId
+			}
+			rr.Router = routerID
+			//the route for the vpc cidr itself
+			if routerID == "local" {
+				rr.RouterName = "local"
+			}
+			rs = append(rs, rr)
+		}
+		rt.Routes = rs
+		asSubnets := make([]string, 0)
+		for _, as := range v.Associations {
+			if as.Main != nil && *as.Main {
+				rt.IsMain = true
+			}
+			if as.SubnetId != nil {
+				asSubnets = append(asSubnets, *as.SubnetId)
+			} else {
+				asSubnets = append(asSubnets, "implicit")
+			}
+		}
+		rt.AssociationSubnets = asSubnets
+		rts = append(rts, rt)
+	}
+	return rts, warns
+}
+
+func parseDescribeSubnetsOutputToSubnets(output *ec2.DescribeSubnetsOutput) ([]*Subnet, []error) {
+	subnets := make([]*Subnet, 0)
+	warns := make([]error, 0)
+	for _, v := range output.Subnets {
+		if v.SubnetId == nil {
+			warns = append(warns, fmt.Errorf("skipped a subnet without SubnetId"))
+			continue
+		}
+		sn := &Subnet{
+			ID:               *v.SubnetId,
+			TagName:          extractTagName(v.Tags),
+			CidrBlock:        stringOrDash(v.CidrBlock),
+			AvailabilityZone: stringOrDash(v.AvailabilityZone),
+			Tags:             extractTags(v.Tags),
+		}
+		if v.AvailabilityZoneId != nil {
+			sn.AvailabilityZoneID = *v.AvailabilityZoneId
+		}
+		if v.AvailableIpAddressCount != nil {
+			sn.AvailableIpAddressCount = aws.Int64(*v.AvailableIpAddressCount)
+		}
+		sn.Ipv6CidrBlocks = make([]string, 0)
+		for _, cbs := range v.Ipv6CidrBlockAssociationSet {
+			if cbs.Ipv6CidrBlock != nil {
+				sn.Ipv6CidrBlocks = append(sn.Ipv6CidrBlocks, *cbs.Ipv6CidrBlock)
+			}
+		}
+		subnets = append(subnets, sn)
+	}
+	return subnets, warns
+}
+
+func parseDescribeNetworkAclsOutputToNetworkAcls(output *ec2.DescribeNetworkAclsOutput) ([]*NetworkAcl, []error) {
+	acls := make([]*NetworkAcl, 0)
+	warns := make([]error, 0)
+	for _, v := range output.NetworkAcls {
+		if v.NetworkAclId == nil {
+			warns = append(warns, fmt.Errorf("skipped a network acl without NetworkAclId"))
+			continue
+		}
+		acl := &NetworkAcl{
+			ID:      *v.NetworkAclId,
+			TagName: extractTagName(v.Tags),
+		}
+		if v.IsDefault != nil {
+			acl.IsDefault = *v.IsDefault
+		}
+		asSubnets := make([]string, 0)
+		for _, as := range v.Associations {
+			if as.SubnetId != nil {
+				asSubnets = append(asSubnets, *as.SubnetId)
+			}
+		}
+		acl.AssociationSubnets = asSubnets
+		entries := make([]*NetworkAclEntry, 0)
+		for _, e := range v.Entries {
+			if e.RuleNumber == nil {
+				warns = append(warns, fmt.Errorf("skipped an entry without RuleNumber in %s", acl.ID))
+				continue
+			}
+			entry := &NetworkAclEntry{
+				RuleNumber: *e.RuleNumber,
+				Protocol:   stringOrDash(e.Protocol),
+				RuleAction: stringOrDash(e.RuleAction),
+			}
+			if e.Egress != nil {
+				entry.Egress = *e.Egress
+			}
+			if e.PortRange != nil && e.PortRange.From != nil && e.PortRange.To != nil {
+				entry.FromPort = *e.PortRange.From
+				entry.ToPort = *e.PortRange.To
+			}
+			if e.CidrBlock != nil {
+				entry.CidrBlock = *e.CidrBlock
+			} else if e.Ipv6CidrBlock != nil {
+				entry.CidrBlock = *e.Ipv6CidrBlock
+			}
+			entries = append(entries, entry)
+		}
+		sort.SliceStable(entries, func(i, j int) bool {
+			if entries[i].Egress != entries[j].Egress {
+				return !entries[i].Egress
+			}
+			return entries[i].RuleNumber < entries[j].RuleNumber
+		})
+		acl.Entries = entries
+		acls = append(acls, acl)
+	}
+	return acls, warns
+}
+
+func parseDescribeVpcEndpointsOutputToVpcEndpoints(output *ec2.DescribeVpcEndpointsOutput) ([]*VpcEndpoint, []error) {
+	endpoints := make([]*VpcEndpoint, 0)
+	warns := make([]error, 0)
+	for _, v := range output.VpcEndpoints {
+		if v.VpcEndpointId == nil {
+			warns = append(warns, fmt.Errorf("skipped a vpc endpoint without VpcEndpointId"))
+			continue
+		}
+		ep := &VpcEndpoint{
+			ID:          *v.VpcEndpointId,
+			TagName:     extractTagName(v.Tags),
+			ServiceName: stringOrDash(v.ServiceName),
+			Type:        stringOrDash(v.VpcEndpointType),
+			State:       stringOrDash(v.State),
+			RouteTables: make([]string, 0),
+			Subnets:     make([]string, 0),
+		}
+		for _, id := range v.RouteTableIds {
+			if id != nil {
+				ep.RouteTables = append(ep.RouteTables, *id)
+			}
+		}
+		for _, id := range v.SubnetIds {
+			if id != nil {
+				ep.Subnets = append(ep.Subnets, *id)
+			}
+		}
+		endpoints = append(endpoints, ep)
+	}
+	return endpoints, warns
+}
+
+func parseDescribeNetworkInterfacesOutputToEnis(output *ec2.DescribeNetworkInterfacesOutput) ([]*Eni, []error) {
+	enis := make([]*Eni, 0)
+	warns := make([]error, 0)
+	for _, v := range output.NetworkInterfaces {
+		if v.NetworkInterfaceId == nil {
+			warns = append(warns, fmt.Errorf("skipped a network interface without NetworkInterfaceId"))
+			continue
+		}
+		eni := &Eni{
+			ID:             *v.NetworkInterfaceId,
+			TagName:        extractTagName(v.TagSet),
+			SubnetID:       stringOrDash(v.SubnetId),
+			PrivateIPs:     make([]string, 0),
+			SecurityGroups: make([]string, 0),
+			AttachmentType: eniAttachmentType(v),
+			Status:         stringOrDash(v.Status),
+		}
+		if v.Description != nil {
+			eni.Description = *v.Description
+		}
+		for _, ip := range v.PrivateIpAddresses {
+			if ip.PrivateIpAddress != nil {
+				eni.PrivateIPs = append(eni.PrivateIPs, *ip.PrivateIpAddress)
+			}
+		}
+		if len(eni.PrivateIPs) == 0 && v.PrivateIpAddress != nil {
+			eni.PrivateIPs = append(eni.PrivateIPs, *v.PrivateIpAddress)
+		}
+		for _, g := range v.Groups {
+			if g.GroupId != nil {
+				eni.SecurityGroups = append(eni.SecurityGroups, *g.GroupId)
+			}
+		}
+		enis = append(enis, eni)
+	}
+	sort.SliceStable(enis, func(i, j int) bool {
+		ipA := net.ParseIP(firstOrEmpty(enis[i].PrivateIPs)).To16()
+		ipB := net.ParseIP(firstOrEmpty(enis[j].PrivateIPs)).To16()
+		return bytes.Compare(ipA, ipB) < 0
+	})
+	return enis, warns
+}
+
+//eniAttachmentType tells what uses the network interface from its InterfaceType, Description and Attachment.
+//Interfaces managed by aws services have the type "interface" and are told by the description.
+func eniAttachmentType(v *ec2.NetworkInterface) string {
+	switch aws.StringValue(v.InterfaceType) {
+	case "nat_gateway":
+		return EniAttachmentNat
+	case "lambda":
+		return EniAttachmentLambda
+	case "network_load_balancer", "gateway_load_balancer", "gateway_load_balancer_endpoint":
+		return EniAttachmentElb
+	case "vpc_endpoint":
+		return EniAttachmentVpcEndpoint
+	case "transit_gateway":
+		return EniAttachmentTransitGateway
+	}
+	description := aws.StringValue(v.Description)
+	switch {
+	case strings.HasPrefix(description, "ELB "):
+		return EniAttachmentElb
+	case strings.HasPrefix(description, "RDSNetworkInterface"):
+		return EniAttachmentRds
+	case strings.HasPrefix(description, "AWS Lambda VPC ENI"):
+		return EniAttachmentLambda
+	case strings.HasPrefix(description, "Interface for NAT Gateway"):
+		return EniAttachmentNat
+	}
+	if v.Attachment != nil && v.Attachment.InstanceId != nil {
+		return EniAttachmentInstance
+	}
+	if v.Attachment == nil {
+		return EniAttachmentNone
+	}
+	return EniAttachmentOther
+}
+
+func firstOrEmpty(ss []string) string {
+	if len(ss) == 0 {
+		return ""
+	}
+	return ss[0]
+}
+
+//BuildEips fetches the elastic ips and tells what each is associated with
+func BuildEips(mng *Manager) ([]*Eip, error) {
+	result, err := mng.FetchAddresses()
+	if err != nil {
+		return nil, err
+	}
+	//the addresses do not tell nat gateways from other network interfaces
+	nats := make(map[string]string)
+	if out, err := mng.FetchNatGateways(); err != nil {
+		return nil, err
+	} else {
+		for _, v := range out.NatGateways {
+			for _, a := range v.NatGatewayAddresses {
+				if a.AllocationId != nil && v.NatGatewayId != nil {
+					nats[*a.AllocationId] = *v.NatGatewayId
+				}
+			}
+		}
+	}
+	eips := make([]*Eip, 0)
+	for _, v := range result.Addresses {
+		eip := &Eip{
+			PublicIP:        stringOrDash(v.PublicIp),
+			AllocationID:    aws.StringValue(v.AllocationId),
+			TagName:         extractTagName(v.Tags),
+			Region:          mng.Region,
+			AssociationType: EipAssociationUnassociated,
+			PrivateIP:       aws.StringValue(v.PrivateIpAddress),
+		}
+		natID, isNat := nats[eip.AllocationID]
+		switch {
+		case v.InstanceId != nil && *v.InstanceId != "":
+			eip.AssociationType, eip.AssociatedWith = EipAssociationInstance, *v.InstanceId
+		case isNat:
+			eip.AssociationType, eip.AssociatedWith = EipAssociationNat, natID
+		case v.NetworkInterfaceId != nil:
+			eip.AssociationType, eip.AssociatedWith = EipAssociationEni, *v.NetworkInterfaceId
+		}
+		eips = append(eips, eip)
+	}
+	sort.SliceStable(eips, func(i, j int) bool {
+		ipA := net.ParseIP(eips[i].PublicIP).To16()
+		ipB := net.ParseIP(eips[j].PublicIP).To16()
+		return bytes.Compare(ipA, ipB) < 0
+	})
+	return eips, nil
+}
+
+func extractTags(tags []*ec2.Tag) map[string]string {
+	m := make(map[string]string)
+	for _, tg := range tags {
+		if tg.Key != nil && tg.Value != nil {
+			m[*tg.Key] = *tg.Value
+		}
+	}
+	return m
+}
+
+func extractTagName(tags []*ec2.Tag) string {
+	var name string
+	for _, tg := range tags {
+		if tg.Key != nil && tg.Value != nil && *tg.Key == "Name" {
+			name = *tg.Value
+		}
+	}
+	return name
+}
+
+func stringOrDash(s *string) string {
+	if s == nil {
+		return "-"
+	}
+	return *s
+}
@@ -0,0 +1,390 @@
+package svc
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//ReportVersion is bumped when the shape of Report changes incompatibly
+const ReportVersion = "1"
+
+//Report is the top level object of the json export.
+//Region is the region the report was requested in. Each vpc has its own region with --all-regions.
+//Partial is true when some fetches failed, with their messages in Errors.
+type Report struct {
+	Version     string   `json:"version"`
+	GeneratedAt string   `json:"generatedAt"`
+	Region      string   `json:"region"`
+	Account     string   `json:"account"`
+	Partial     bool     `json:"partial"`
+	Errors      []string `json:"errors"`
+	Vpcs        []*Vpc   `json:"vpcs"`
+	Eips        []*Eip   `json:"eips,omitempty"`
+}
+
+func NewReport(region, account string, vpcs []*Vpc) *Report {
+	if vpcs == nil {
+		vpcs = make([]*Vpc, 0)
+	}
+	return &Report{
+		Version:     ReportVersion,
+		GeneratedAt: time.Now().UTC().Format(time.RFC3339),
+		Region:      region,
+		Account:     account,
+		Errors:      make([]string, 0),
+		Vpcs:        vpcs,
+	}
+}
+
+//SetErrors marks the report partial when errs is not empty
+func (r *Report) SetErrors(errs []error) {
+	r.Errors = make([]string, 0, len(errs))
+	for _, e := range errs {
+		r.Errors = append(r.Errors, e.Error())
+	}
+	r.Partial = len(r.Errors) > 0
+}
+
+type Vpc struct {
+	ID                        string                      `json:"id"`
+	Region                    string                      `json:"region"`
+	Account                   string                      `json:"account"`
+	TagName                   string                      `json:"tagName"`
+	CidrBlock                 string                      `json:"cidrBlock"`
+	IsDefault                 bool                        `json:"isDefault"`
+	DhcpOptionsID             string                      `json:"dhcpOptionsId"`
+	DhcpOptions               *DhcpOptions                `json:"dhcpOptions,omitempty"`
+	FlowLogs                  []*FlowLog                  `json:"flowLogs"` //nil when flow logs could not be fetched
+	AssociatedCidrBlocks      []string                    `json:"associatedCidrBlocks"`
+	Ipv6CidrBlocks            []string                    `json:"ipv6CidrBlocks"`
+	RouteTables               []*RouteTable               `json:"routeTables"`
+	Subnets                   []*Subnet                   `json:"subnets"`
+	NetworkAcls               []*NetworkAcl               `json:"networkAcls"`
+	VpcEndpoints              []*VpcEndpoint              `json:"vpcEndpoints"`
+	TransitGatewayAttachments []*TransitGatewayAttachment `json:"transitGatewayAttachments"`
+	PeeringConnections        []*PeeringConnection        `json:"peeringConnections"`
+	//HaWarnings tell subnets concentrated in a single availability zone
+	HaWarnings []string `json:"haWarnings,omitempty"`
+	//Tags are those of NetworkOptions.ShowTags
+	Tags map[string]string `json:"tags,omitempty"`
+}
+
+//FlowLog is a flow log of a vpc
+type FlowLog struct {
+	ID              string `json:"id"`
+	DestinationType string `json:"destinationType"` //cloud-watch-logs, s3 or kinesis-data-firehose
+	Destination     string `json:"destination"`
+	TrafficType     string `json:"trafficType"`
+	Status          string `json:"status"`
+}
+
+//FlowLogsEnabled reports whether the vpc has an active flow log
+func (v *Vpc) FlowLogsEnabled() bool {
+	for _, fl := range v.FlowLogs {
+		if fl.Status == "ACTIVE" {
+			return true
+		}
+	}
+	return false
+}
+
+//DhcpOptions is the dhcp options set of a vpc
+type DhcpOptions struct {
+	ID                string   `json:"id"`
+	TagName           string   `json:"tagName"`
+	DomainName        string   `json:"domainName"`
+	DomainNameServers []string `json:"domainNameServers"`
+	NtpServers        []string `json:"ntpServers"`
+}
+
+type RouteTable struct {
+	ID                 string   `json:"id"`
+	TagName            string   `json:"tagName"`
+	IsMain             bool     `json:"isMain"`
+	Routes             []*Route `json:"routes"`
+	AssociationSubnets []string `json:"associationSubnets"` //subnet-id
+}
+
+//MainRouteTable returns the route table governing the subnets without explicit association, or nil
+func (v *Vpc) MainRouteTable() *RouteTable {
+	for _, rt := range v.RouteTables {
+		if rt.IsMain {
+			return rt
+		}
+	}
+	return nil
+}
+
+type Route struct {
+	DestinationCidrBlock         string   `json:"destinationCidrBlock"`
+	DestinationIpv6CidrBlock     string   `json:"destinationIpv6CidrBlock,omitempty"`
+	DestinationPrefixListID      string   `json:"destinationPrefixListId,omitempty"`
+	DestinationPrefixListName    string   `json:"destinationPrefixListName,omitempty"`
+	DestinationPrefixListEntries []string `json:"destinationPrefixListEntries,omitempty"`
+	Router                       string   `json:"router"`
+	RouterName                   string   `json:"routerName,omitempty"`
+}
+
+//Destination returns the ipv4 or ipv6 cidr, or the prefix list with its name if resolved
+func (r *Route) Destination() string {
+	if r.DestinationIpv6CidrBlock != "" {
+		return r.DestinationIpv6CidrBlock
+	}
+	if r.DestinationPrefixListID == "" {
+		return r.DestinationCidrBlock
+	}
+	if r.DestinationPrefixListName == "" {
+		return r.DestinationPrefixListID
+	}
+	return fmt.Sprintf("%s (%s)", r.DestinationPrefixListID, r.DestinationPrefixListName)
+}
+
+//Target returns the resolved name of the router, or its id when unresolved
+func (r *Route) Target() string {
+	if r.RouterName != "" {
+		return r.RouterName
+	}
+	return r.Router
+}
+
+type Subnet struct {
+	ID                      string            `json:"id"`
+	TagName                 string            `json:"tagName"`
+	CidrBlock               string            `json:"cidrBlock"`
+	Ipv6CidrBlocks          []string          `json:"ipv6CidrBlocks"`
+	AvailabilityZone        string            `json:"availabilityZone"`
+	AvailabilityZoneID      string            `json:"availabilityZoneId"`
+	AvailableIpAddressCount *int64            `json:"availableIpAddressCount"` //nil when not returned by the api
+	OverlappingSubnets      []string          `json:"overlappingSubnets,omitempty"`
+	Enis                    []*Eni            `json:"enis,omitempty"`
+	InstanceCount           *int              `json:"instanceCount,omitempty"` //nil unless NetworkOptions.IncludeInstanceCounts
+	Tags                    map[string]string `json:"tags,omitempty"`          //those of NetworkOptions.ShowTags
+	AssociatedRouteTable    *RouteTable       `json:"-"`
+	AssociatedNetworkAcl    *NetworkAcl       `json:"-"`
+	//EffectiveRouteTable is AssociatedRouteTable, or the main route table of the vpc for a subnet without explicit association
+	EffectiveRouteTable *RouteTable `json:"-"`
+}
+
+//IsPublic reports whether the route table in effect routes 0.0.0.0/0 to an internet gateway
+func (sn *Subnet) IsPublic() bool {
+	if sn.EffectiveRouteTable == nil {
+		return false
+	}
+	for _, r := range sn.EffectiveRouteTable.Routes {
+		if r.DestinationCidrBlock == "0.0.0.0/0" && strings.HasPrefix(r.Router, "igw-") {
+			return true
+		}
+	}
+	return false
+}
+
+//NearlyFullUtilization is the ratio of used ips above which a subnet is reported as running out of capacity
+const NearlyFullUtilization = 0.8
+
+//UsableIPs returns the number of ips in the cidr minus the 5 aws reserves, or 0 when the cidr is invalid.
+//Only the prefix length is read so that it works with redacted cidrs.
+func (sn *Subnet) UsableIPs() int64 {
+	i := strings.LastIndex(sn.CidrBlock, "/")
+	if i < 0 {
+		return 0
+	}
+	ones, err := strconv.Atoi(sn.CidrBlock[i+1:])
+	if err != nil || ones < 0 || ones > 32 {
+		return 0
+	}
+	usable := int64(1)<<uint(32-ones) - 5
+	if usable < 0 {
+		return 0
+	}
+	return usable
+}
+
+//Utilization returns the ratio of used ips to usable ips, or 0 when the available ips are unknown
+func (sn *Subnet) Utilization() float64 {
+	usable := sn.UsableIPs()
+	if usable == 0 || sn.AvailableIpAddressCount == nil {
+		return 0
+	}
+	return float64(usable-*sn.AvailableIpAddressCount) / float64(usable)
+}
+
+//UtilizationLabel is the utilization in percent, or - when the available ips are unknown
+func (sn *Subnet) UtilizationLabel() string {
+	if sn.AvailableIpAddressCount == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%.0f%%", sn.Utilization()*100)
+}
+
+//AvailableIPsLabel is the number of available ips, or - when unknown
+func (sn *Subnet) AvailableIPsLabel() string {
+	if sn.AvailableIpAddressCount == nil {
+		return "-"
+	}
+	return strconv.FormatInt(*sn.AvailableIpAddressCount, 10)
+}
+
+func (sn *Subnet) IsNearlyFull() bool {
+	return sn.Utilization() > NearlyFullUtilization
+}
+
+//MarshalJSON emits only the ids of AssociatedRouteTable and AssociatedNetworkAcl
+func (sn *Subnet) MarshalJSON() ([]byte, error) {
+	type subnet Subnet
+	var rtID, aclID string
+	if sn.AssociatedRouteTable != nil {
+		rtID = sn.AssociatedRouteTable.ID
+	}
+	if sn.AssociatedNetworkAcl != nil {
+		aclID = sn.AssociatedNetworkAcl.ID
+	}
+	return json.Marshal(&struct {
+		*subnet
+		AssociatedRouteTableID string `json:"associatedRouteTableId"`
+		AssociatedNetworkAclID string `json:"associatedNetworkAclId"`
+	}{
+		subnet:                 (*subnet)(sn),
+		AssociatedRouteTableID: rtID,
+		AssociatedNetworkAclID: aclID,
+	})
+}
+
+type NetworkAcl struct {
+	ID                 string             `json:"id"`
+	TagName            string             `json:"tagName"`
+	IsDefault          bool               `json:"isDefault"`
+	AssociationSubnets []string           `json:"associationSubnets"` //subnet-id
+	Entries            []*NetworkAclEntry `json:"entries"`
+}
+
+type NetworkAclEntry struct {
+	RuleNumber int64  `json:"ruleNumber"`
+	Egress     bool   `json:"egress"`
+	Protocol   string `json:"protocol"`
+	FromPort   int64  `json:"fromPort"`
+	ToPort     int64  `json:"toPort"`
+	CidrBlock  string `json:"cidrBlock"`
+	RuleAction string `json:"ruleAction"`
+}
+
+//IsDefaultDeny reports whether the entry is the catch-all deny rule every nacl has
+func (e *NetworkAclEntry) IsDefaultDeny() bool {
+	return e.RuleNumber == 32767 && e.RuleAction == "deny"
+}
+
+//IsBroadAllow reports whether the entry allows all traffic from/to anywhere
+func (e *NetworkAclEntry) IsBroadAllow() bool {
+	return e.RuleAction == "allow" && e.Protocol == "-1" && (e.CidrBlock == "0.0.0.0/0" || e.CidrBlock == "::/0")
+}
+
+func (e *NetworkAclEntry) ProtocolName() string {
+	switch e.Protocol {
+	case "-1":
+		return "all"
+	case "1":
+		return "icmp"
+	case "6":
+		return "tcp"
+	case "17":
+		return "udp"
+	}
+	return e.Protocol
+}
+
+func (e *NetworkAclEntry) PortRange() string {
+	if e.Protocol == "-1" {
+		return "all"
+	}
+	return fmt.Sprintf("%d - %d", e.FromPort, e.ToPort)
+}
+
+type VpcEndpoint struct {
+	ID          string   `json:"id"`
+	TagName     string   `json:"tagName"`
+	ServiceName string   `json:"serviceName"`
+	Type        string   `json:"type"`
+	State       string   `json:"state"`
+	RouteTables []string `json:"routeTables"` //route-table-id, Gateway type only
+	Subnets     []string `json:"subnets"`     //subnet-id, Interface and GatewayLoadBalancer types
+}
+
+//TransitGatewayAttachment is an attachment of a vpc to a transit gateway
+type TransitGatewayAttachment struct {
+	ID                 string `json:"id"`
+	TagName            string `json:"tagName"`
+	TransitGatewayID   string `json:"transitGatewayId"`
+	TransitGatewayName string `json:"transitGatewayName"`
+	ResourceType       string `json:"resourceType"`
+	State              string `json:"state"`
+}
+
+//Eni attachment types
+const (
+	EniAttachmentInstance       = "instance"
+	EniAttachmentNat            = "nat"
+	EniAttachmentElb            = "elb"
+	EniAttachmentLambda         = "lambda"
+	EniAttachmentRds            = "rds"
+	EniAttachmentVpcEndpoint    = "vpc-endpoint"
+	EniAttachmentTransitGateway = "transit-gateway"
+	EniAttachmentOther          = "other"
+	EniAttachmentNone           = "unattached"
+)
+
+//Eni is a network interface in a subnet
+type Eni struct {
+	ID             string   `json:"id"`
+	TagName        string   `json:"tagName"`
+	SubnetID       string   `json:"subnetId"`
+	PrivateIPs     []string `json:"privateIps"`
+	SecurityGroups []string `json:"securityGroups"`
+	AttachmentType string   `json:"attachmentType"`
+	Description    string   `json:"description"`
+	Status         string   `json:"status"`
+}
+
+//PeeringConnection is a vpc peering connection the vpc is the requester or accepter of
+type PeeringConnection struct {
+	ID        string      `json:"id"`
+	TagName   string      `json:"tagName"`
+	Requester *PeeringVpc `json:"requester"`
+	Accepter  *PeeringVpc `json:"accepter"`
+	Status    string      `json:"status"` //active, pending-acceptance, deleted etc.
+}
+
+type PeeringVpc struct {
+	VpcID     string `json:"vpcId"`
+	CidrBlock string `json:"cidrBlock"`
+	OwnerID   string `json:"ownerId"`
+	Region    string `json:"region"`
+}
+
+func (pc *PeeringConnection) IsActive() bool {
+	return pc.Status == "active"
+}
+
+//Eip association types
+const (
+	EipAssociationInstance     = "instance"
+	EipAssociationNat          = "nat"
+	EipAssociationEni          = "eni"
+	EipAssociationUnassociated = "unassociated"
+)
+
+//Eip is an elastic ip. Those unassociated are billed without being used.
+type Eip struct {
+	PublicIP        string `json:"publicIp"`
+	AllocationID    string `json:"allocationId"`
+	TagName         string `json:"tagName"`
+	Region          string `json:"region"`
+	AssociationType string `json:"associationType"`
+	AssociatedWith  string `json:"associatedWith"` //instance, nat gateway or network interface id
+	PrivateIP       string `json:"privateIp"`
+}
+
+func (e *Eip) IsAssociated() bool {
+	return e.AssociationType != EipAssociationUnassociated
+}
@@ -0,0 +1,227 @@
+package svc
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+)
+
+//fakeEC2 returns the canned pages of the describe calls. The calls not overridden panic.
+type fakeEC2 struct {
+	ec2iface.EC2API
+	vpcPages        []*ec2.DescribeVpcsOutput
+	subnetPages     []*ec2.DescribeSubnetsOutput
+	routeTablePages []*ec2.DescribeRouteTablesOutput
+}
+
+func (f *fakeEC2) DescribeVpcsPagesWithContext(ctx aws.Context, input *ec2.DescribeVpcsInput, fn func(*ec2.DescribeVpcsOutput, bool) bool, opts ...request.Option) error {
+	for i, page := range f.vpcPages {
+		if !fn(page, i == len(f.vpcPages)-1) {
+			break
+		}
+	}
+	return nil
+}
+
+func (f *fakeEC2) DescribeSubnetsPagesWithContext(ctx aws.Context, input *ec2.DescribeSubnetsInput, fn func(*ec2.DescribeSubnetsOutput, bool) bool, opts ...request.Option) error {
+	for i, page := range f.subnetPages {
+		if !fn(page, i == len(f.subnetPages)-1) {
+			break
+		}
+	}
+	return nil
+}
+
+func (f *fakeEC2) DescribeRouteTablesPagesWithContext(ctx aws.Context, input *ec2.DescribeRouteTablesInput, fn func(*ec2.DescribeRouteTablesOutput, bool) bool, opts ...request.Option) error {
+	for i, page := range f.routeTablePages {
+		if !fn(page, i == len(f.routeTablePages)-1) {
+			break
+		}
+	}
+	return nil
+}
+
+func newFakeManager(fake *fakeEC2) *Manager {
+	return &Manager{
+		EC2Client: &EC2Client{EC2API: fake, ctx: context.Background()},
+		Region:    "ap-northeast-1",
+	}
+}
+
+func TestParseDescribeRouteTablesOutputToRouteTables(t *testing.T) {
+	tests := []struct {
+		name      string
+		route     *ec2.Route
+		want      []*Route
+		wantWarns int
+	}{
+		{
+			name:  "internet gateway",
+			route: &ec2.Route{DestinationCidrBlock: aws.String("0.0.0.0/0"), GatewayId: aws.String("igw-1")},
+			want:  []*Route{{DestinationCidrBlock: "0.0.0.0/0", Router: "igw-1"}},
+		},
+		{
+			name:  "local",
+			route: &ec2.Route{DestinationCidrBlock: aws.String("10.0.0.0/16"), GatewayId: aws.String("local")},
+			want:  []*Route{{DestinationCidrBlock: "10.0.0.0/16", Router: "local", RouterName: "local"}},
+		},
+		{
+			name:  "nat gateway",
+			route: &ec2.Route{DestinationCidrBlock: aws.String("0.0.0.0/0"), NatGatewayId: aws.String("nat-1")},
+			want:  []*Route{{DestinationCidrBlock: "0.0.0.0/0", Router: "nat-1"}},
+		},
+		{
+			name:  "peering",
+			route: &ec2.Route{DestinationCidrBlock: aws.String("172.16.0.0/16"), VpcPeeringConnectionId: aws.String("pcx-1")},
+			want:  []*Route{{DestinationCidrBlock: "172.16.0.0/16", Router: "pcx-1"}},
+		},
+		{
+			name:  "ipv6 egress only gateway",
+			route: &ec2.Route{DestinationIpv6CidrBlock: aws.String("::/0"), EgressOnlyInternetGatewayId: aws.String("eigw-1")},
+			want:  []*Route{{DestinationIpv6CidrBlock: "::/0", Router: "eigw-1"}},
+		},
+		{
+			name:      "nil destination",
+			route:     &ec2.Route{GatewayId: aws.String("igw-1")},
+			want:      []*Route{},
+			wantWarns: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := &ec2.DescribeRouteTablesOutput{
+				RouteTables: []*ec2.RouteTable{{
+					RouteTableId: aws.String("rtb-1"),
+					Routes:       []*ec2.Route{tt.route},
+				}},
+			}
+			rts, warns := parseDescribeRouteTablesOutputToRouteTables(output)
+			if len(warns) != tt.wantWarns {
+				t.Errorf("got %d warns, want %d: %v", len(warns), tt.wantWarns, warns)
+			}
+			if len(rts) != 1 {
+				t.Fatalf("got %d route tables, want 1", len(rts))
+			}
+			if !reflect.DeepEqual(rts[0].Routes, tt.want) {
+				t.Errorf("got routes %+v, want %+v", rts[0].Routes, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDescribeRouteTablesOutputToRouteTablesWithoutID(t *testing.T) {
+	output := &ec2.DescribeRouteTablesOutput{
+		RouteTables: []*ec2.RouteTable{{}},
+	}
+	rts, warns := parseDescribeRouteTablesOutputToRouteTables(output)
+	if len(rts) != 0 || len(warns) != 1 {
+		t.Errorf("got %d route tables and %d warns, want 0 and 1", len(rts), len(warns))
+	}
+}
+
+//TestAssociateRouteTableSubnet checks a subnet without explicit association is left to the main route table
+func TestAssociateRouteTableSubnet(t *testing.T) {
+	fake := &fakeEC2{
+		vpcPages: []*ec2.DescribeVpcsOutput{{
+			Vpcs: []*ec2.Vpc{{VpcId: aws.String("vpc-1"), CidrBlock: aws.String("10.0.0.0/16")}},
+		}},
+		subnetPages: []*ec2.DescribeSubnetsOutput{{
+			Subnets: []*ec2.Subnet{
+				{SubnetId: aws.String("subnet-explicit"), CidrBlock: aws.String("10.0.1.0/24")},
+				{SubnetId: aws.String("subnet-implicit"), CidrBlock: aws.String("10.0.2.0/24")},
+			},
+		}},
+		routeTablePages: []*ec2.DescribeRouteTablesOutput{{
+			RouteTables: []*ec2.RouteTable{
+				{
+					RouteTableId: aws.String("rtb-main"),
+					Associations: []*ec2.RouteTableAssociation{{Main: aws.Bool(true)}},
+				},
+				{
+					RouteTableId: aws.String("rtb-app"),
+					Associations: []*ec2.RouteTableAssociation{{SubnetId: aws.String("subnet-explicit")}},
+				},
+			},
+		}},
+	}
+	b := &networkBuilder{manager: newFakeManager(fake)}
+	b.constructVpcs().
+		constructSubnets().
+		constructRouteTables().
+		associateRouteTableSubnet()
+	if err := b.flattenErrs(); err != nil {
+		t.Fatal(err)
+	}
+	if len(b.vpcs) != 1 {
+		t.Fatalf("got %d vpcs, want 1", len(b.vpcs))
+	}
+	vpc := b.vpcs[0]
+	subnets := make(map[string]*Subnet)
+	for _, sn := range vpc.Subnets {
+		subnets[sn.ID] = sn
+	}
+	if rt := subnets["subnet-explicit"].AssociatedRouteTable; rt == nil || rt.ID != "rtb-app" {
+		t.Errorf("subnet-explicit is associated with %+v, want rtb-app", rt)
+	}
+	if rt := subnets["subnet-implicit"].AssociatedRouteTable; rt != nil {
+		t.Errorf("subnet-implicit is associated with %s, want none", rt.ID)
+	}
+	main := vpc.MainRouteTable()
+	if main == nil || main.ID != "rtb-main" {
+		t.Fatalf("got main route table %+v, want rtb-main", main)
+	}
+	if !reflect.DeepEqual(main.AssociationSubnets, []string{"implicit"}) {
+		t.Errorf("got association subnets %v of the main route table, want [implicit]", main.AssociationSubnets)
+	}
+}
+
+//TestSubnetIsPublicThroughMainRouteTable checks a subnet without explicit association is public by the routes of the main route table
+func TestSubnetIsPublicThroughMainRouteTable(t *testing.T) {
+	fake := &fakeEC2{
+		vpcPages: []*ec2.DescribeVpcsOutput{{
+			Vpcs: []*ec2.Vpc{{VpcId: aws.String("vpc-1"), CidrBlock: aws.String("10.0.0.0/16")}},
+		}},
+		subnetPages: []*ec2.DescribeSubnetsOutput{{
+			Subnets: []*ec2.Subnet{
+				{SubnetId: aws.String("subnet-private"), CidrBlock: aws.String("10.0.1.0/24")},
+				{SubnetId: aws.String("subnet-implicit"), CidrBlock: aws.String("10.0.2.0/24")},
+			},
+		}},
+		routeTablePages: []*ec2.DescribeRouteTablesOutput{{
+			RouteTables: []*ec2.RouteTable{
+				{
+					RouteTableId: aws.String("rtb-main"),
+					Associations: []*ec2.RouteTableAssociation{{Main: aws.Bool(true)}},
+					Routes:       []*ec2.Route{{DestinationCidrBlock: aws.String("0.0.0.0/0"), GatewayId: aws.String("igw-1")}},
+				},
+				{
+					RouteTableId: aws.String("rtb-private"),
+					Associations: []*ec2.RouteTableAssociation{{SubnetId: aws.String("subnet-private")}},
+					Routes:       []*ec2.Route{{DestinationCidrBlock: aws.String("0.0.0.0/0"), NatGatewayId: aws.String("nat-1")}},
+				},
+			},
+		}},
+	}
+	b := &networkBuilder{manager: newFakeManager(fake)}
+	b.constructVpcs().
+		constructSubnets().
+		constructRouteTables().
+		associateRouteTableSubnet()
+	if err := b.flattenErrs(); err != nil {
+		t.Fatal(err)
+	}
+	for _, sn := range b.vpcs[0].Subnets {
+		want := sn.ID == "subnet-implicit"
+		if got := sn.IsPublic(); got != want {
+			t.Errorf("%s: got public %v, want %v", sn.ID, got, want)
+		}
+		if want && sn.AssociatedRouteTable != nil {
+			t.Errorf("%s is associated with %s, want none", sn.ID, sn.AssociatedRouteTable.ID)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package svc
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/rds"
+)
+
+type RDSClient struct {
+	*rds.RDS
+	ctx aws.Context
+}
+
+func (c *RDSClient) FetchDBInstances() (*rds.DescribeDBInstancesOutput, error) {
+	input := &rds.DescribeDBInstancesInput{}
+	output := &rds.DescribeDBInstancesOutput{}
+	err := c.DescribeDBInstancesPagesWithContext(c.ctx, input, func(page *rds.DescribeDBInstancesOutput, lastPage bool) bool {
+		output.DBInstances = append(output.DBInstances, page.DBInstances...)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return output, nil
+}
@@ -0,0 +1,39 @@
+package svc
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+)
+
+type Route53Client struct {
+	*route53.Route53
+	ctx aws.Context
+}
+
+func (c *Route53Client) FetchHostedZones() (*route53.ListHostedZonesOutput, error) {
+	input := &route53.ListHostedZonesInput{}
+	output := &route53.ListHostedZonesOutput{}
+	err := c.ListHostedZonesPagesWithContext(c.ctx, input, func(page *route53.ListHostedZonesOutput, lastPage bool) bool {
+		output.HostedZones = append(output.HostedZones, page.HostedZones...)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+func (c *Route53Client) FetchResourceRecordSets(zoneID string) (*route53.ListResourceRecordSetsOutput, error) {
+	input := &route53.ListResourceRecordSetsInput{
+		HostedZoneId: aws.String(zoneID),
+	}
+	output := &route53.ListResourceRecordSetsOutput{}
+	err := c.ListResourceRecordSetsPagesWithContext(c.ctx, input, func(page *route53.ListResourceRecordSetsOutput, lastPage bool) bool {
+		output.ResourceRecordSets = append(output.ResourceRecordSets, page.ResourceRecordSets...)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return output, nil
+}
@@ -0,0 +1,197 @@
+package svc
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+)
+
+type S3Client struct {
+	*s3.S3
+	sess *session.Session
+	ctx  aws.Context
+}
+
+//FetchObject downloads the object from the region the bucket is in.
+//The latest version is fetched when versionID is empty.
+func (c *S3Client) FetchObject(bucket, key, versionID string) ([]byte, error) {
+	client, err := c.clientForBucket(bucket)
+	if err != nil {
+		return nil, err
+	}
+	input := &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	}
+	if versionID != "" {
+		input.VersionId = aws.String(versionID)
+	}
+	output, err := client.GetObjectWithContext(c.ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	if output.Body == nil {
+		return []byte{}, nil
+	}
+	defer output.Body.Close()
+	return ioutil.ReadAll(output.Body)
+}
+
+//UploadObject uploads body and returns the url of the object
+func (c *S3Client) UploadObject(bucket, key, contentType string, body io.Reader) (string, error) {
+	client, err := c.clientForBucket(bucket)
+	if err != nil {
+		return "", err
+	}
+	uploader := s3manager.NewUploaderWithClient(client)
+	output, err := uploader.UploadWithContext(c.ctx, &s3manager.UploadInput{
+		Bucket:      aws.String(bucket),
+		Key:         aws.String(key),
+		ContentType: aws.String(contentType),
+		Body:        body,
+	})
+	if err != nil {
+		return "", err
+	}
+	return output.Location, nil
+}
+
+//FetchBuckets lists the buckets with their regions, public access settings and default encryption.
+//A bucket whose checks failed is listed with those checks unknown, and the errors are returned with the buckets.
+func (c *S3Client) FetchBuckets() ([]*Bucket, []error) {
+	output, err := c.ListBucketsWithContext(c.ctx, &s3.ListBucketsInput{})
+	if err != nil {
+		return nil, []error{err}
+	}
+	buckets := make([]*Bucket, 0, len(output.Buckets))
+	errs := make([]error, 0)
+	clients := make(map[string]*s3.S3)
+	for _, v := range output.Buckets {
+		if v.Name == nil {
+			continue
+		}
+		b, bucketErrs := c.fetchBucket(*v.Name, clients)
+		for _, err := range bucketErrs {
+			errs = append(errs, fmt.Errorf("%s: %w", *v.Name, err))
+		}
+		buckets = append(buckets, b)
+	}
+	sort.Slice(buckets, func(i, j int) bool {
+		return buckets[i].Name < buckets[j].Name
+	})
+	return buckets, errs
+}
+
+//fetchBucket sets the checks which failed to unknown and returns their errors
+func (c *S3Client) fetchBucket(name string, clients map[string]*s3.S3) (*Bucket, []error) {
+	b := &Bucket{
+		Name:              name,
+		Region:            BucketUnknown,
+		PublicAccessBlock: BucketUnknown,
+		Encryption:        BucketUnknown,
+	}
+	location, err := c.GetBucketLocationWithContext(c.ctx, &s3.GetBucketLocationInput{Bucket: aws.String(name)})
+	if err != nil {
+		//the other checks need the client of the region
+		return b, []error{err}
+	}
+	b.Region = s3.NormalizeBucketLocation(aws.StringValue(location.LocationConstraint))
+	client, ok := clients[b.Region]
+	if !ok {
+		client = s3.New(c.sess, &aws.Config{Region: aws.String(b.Region)})
+		clients[b.Region] = client
+	}
+	errs := make([]error, 0)
+	pab, err := client.GetPublicAccessBlockWithContext(c.ctx, &s3.GetPublicAccessBlockInput{Bucket: aws.String(name)})
+	switch {
+	case isAwsErrorCode(err, "NoSuchPublicAccessBlockConfiguration"):
+		b.PublicAccessBlock = PublicAccessBlockNone
+	case err != nil:
+		errs = append(errs, err)
+	default:
+		b.PublicAccessBlock = PublicAccessBlockNone
+		if conf := pab.PublicAccessBlockConfiguration; conf != nil {
+			b.BlockPublicAcls = aws.BoolValue(conf.BlockPublicAcls)
+			b.IgnorePublicAcls = aws.BoolValue(conf.IgnorePublicAcls)
+			b.BlockPublicPolicy = aws.BoolValue(conf.BlockPublicPolicy)
+			b.RestrictPublicBuckets = aws.BoolValue(conf.RestrictPublicBuckets)
+			on := 0
+			for _, v := range []bool{b.BlockPublicAcls, b.IgnorePublicAcls, b.BlockPublicPolicy, b.RestrictPublicBuckets} {
+				if v {
+					on++
+				}
+			}
+			switch on {
+			case 4:
+				b.PublicAccessBlock = PublicAccessBlockAll
+			case 0:
+			default:
+				b.PublicAccessBlock = PublicAccessBlockPartial
+			}
+		}
+	}
+	status, err := client.GetBucketPolicyStatusWithContext(c.ctx, &s3.GetBucketPolicyStatusInput{Bucket: aws.String(name)})
+	switch {
+	case isAwsErrorCode(err, "NoSuchBucketPolicy"):
+		b.PolicyPublic = aws.Bool(false)
+	case err != nil:
+		errs = append(errs, err)
+	default:
+		b.PolicyPublic = aws.Bool(status.PolicyStatus != nil && aws.BoolValue(status.PolicyStatus.IsPublic))
+	}
+	if acl, err := client.GetBucketAclWithContext(c.ctx, &s3.GetBucketAclInput{Bucket: aws.String(name)}); err != nil {
+		errs = append(errs, err)
+	} else {
+		b.AclPublic = aws.Bool(false)
+		for _, g := range acl.Grants {
+			if g.Grantee == nil {
+				continue
+			}
+			switch aws.StringValue(g.Grantee.URI) {
+			case "http://acs.amazonaws.com/groups/global/AllUsers", "http://acs.amazonaws.com/groups/global/AuthenticatedUsers":
+				b.AclPublic = aws.Bool(true)
+			}
+		}
+	}
+	enc, err := client.GetBucketEncryptionWithContext(c.ctx, &s3.GetBucketEncryptionInput{Bucket: aws.String(name)})
+	switch {
+	case isAwsErrorCode(err, "ServerSideEncryptionConfigurationNotFoundError"):
+		b.Encryption = "none"
+	case err != nil:
+		errs = append(errs, err)
+	default:
+		b.Encryption = "none"
+		if enc.ServerSideEncryptionConfiguration != nil {
+			for _, r := range enc.ServerSideEncryptionConfiguration.Rules {
+				if r.ApplyServerSideEncryptionByDefault != nil {
+					b.Encryption = aws.StringValue(r.ApplyServerSideEncryptionByDefault.SSEAlgorithm)
+				}
+			}
+		}
+	}
+	return b, errs
+}
+
+func isAwsErrorCode(err error, code string) bool {
+	aerr, ok := err.(awserr.Error)
+	return ok && aerr.Code() == code
+}
+
+//clientForBucket returns a client for the region the bucket is in
+func (c *S3Client) clientForBucket(bucket string) (*s3.S3, error) {
+	region, err := s3manager.GetBucketRegionWithClient(c.ctx, c.S3, bucket)
+	if err != nil {
+		return nil, err
+	}
+	if region == aws.StringValue(c.Config.Region) {
+		return c.S3, nil
+	}
+	return s3.New(c.sess, &aws.Config{Region: aws.String(region)}), nil
+}
@@ -0,0 +1,53 @@
+package svc
+
+//Bucket is an s3 bucket with its public access settings
+type Bucket struct {
+	Name   string `json:"name"`
+	Region string `json:"region"`
+	//PublicAccessBlock is all, partial or none by how many of the 4 settings of the bucket are on.
+	//That of the account is not considered.
+	PublicAccessBlock string `json:"publicAccessBlock"`
+	//BlockPublicAcls, IgnorePublicAcls, BlockPublicPolicy and RestrictPublicBuckets are the settings counted by PublicAccessBlock
+	BlockPublicAcls       bool `json:"blockPublicAcls"`
+	IgnorePublicAcls      bool `json:"ignorePublicAcls"`
+	BlockPublicPolicy     bool `json:"blockPublicPolicy"`
+	RestrictPublicBuckets bool `json:"restrictPublicBuckets"`
+	//PolicyPublic is true when the bucket policy grants access to anyone, nil when the check failed
+	PolicyPublic *bool `json:"policyPublic"`
+	//AclPublic is true when the acl grants to all users or all authenticated users, nil when the check failed
+	AclPublic  *bool  `json:"aclPublic"`
+	Encryption string `json:"encryption"`
+}
+
+//Public access block settings
+const (
+	PublicAccessBlockAll     = "all"
+	PublicAccessBlockPartial = "partial"
+	PublicAccessBlockNone    = "none"
+)
+
+//BucketUnknown is the region, public access block or encryption of a bucket whose check failed
+const BucketUnknown = "unknown"
+
+//IsPublic reports whether the policy or acl grants public access not blocked by the bucket.
+//IgnorePublicAcls cancels the acl and RestrictPublicBuckets the policy, while BlockPublicAcls and
+//BlockPublicPolicy only reject new grants.
+func (b *Bucket) IsPublic() bool {
+	return b.IsAclPublic() || b.IsPolicyPublic()
+}
+
+//IsAclPublic reports whether the acl grants public access not ignored by IgnorePublicAcls
+func (b *Bucket) IsAclPublic() bool {
+	return b.AclPublic != nil && *b.AclPublic && !b.IgnorePublicAcls
+}
+
+//IsPolicyPublic reports whether the policy grants public access not restricted by RestrictPublicBuckets
+func (b *Bucket) IsPolicyPublic() bool {
+	return b.PolicyPublic != nil && *b.PolicyPublic && !b.RestrictPublicBuckets
+}
+
+//IsIncomplete reports whether any check of the bucket failed
+func (b *Bucket) IsIncomplete() bool {
+	return b.Region == BucketUnknown || b.PublicAccessBlock == BucketUnknown || b.Encryption == BucketUnknown ||
+		b.PolicyPublic == nil || b.AclPublic == nil
+}
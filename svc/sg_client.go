@@ -3,32 +3,46 @@ package svc
 import (
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
 )
 
 type SGClient struct {
-	*ec2.EC2
+	ec2iface.EC2API
+	ctx aws.Context
 }
 
 func (c *SGClient) FetchSecurityGroups() (*ec2.DescribeSecurityGroupsOutput, error) {
 	input := &ec2.DescribeSecurityGroupsInput{}
-	return c.DescribeSecurityGroups(input)
+	return c.fetchSecurityGroupsPages(input)
 }
 
-func (c *SGClient) FetchNetworkInterfaces(gids []*string) (*ec2.DescribeNetworkInterfacesOutput, error) {
-	input := &ec2.DescribeNetworkInterfacesInput{
+func (c *SGClient) FetchSecurityGroupsWithVpc(vpcID string) (*ec2.DescribeSecurityGroupsOutput, error) {
+	input := &ec2.DescribeSecurityGroupsInput{
 		Filters: []*ec2.Filter{
 			&ec2.Filter{
-				Name:   aws.String("group-id"),
-				Values: gids,
+				Name:   aws.String("vpc-id"),
+				Values: []*string{aws.String(vpcID)},
 			},
 		},
 	}
-	return c.DescribeNetworkInterfaces(input)
+	return c.fetchSecurityGroupsPages(input)
+}
+
+func (c *SGClient) fetchSecurityGroupsPages(input *ec2.DescribeSecurityGroupsInput) (*ec2.DescribeSecurityGroupsOutput, error) {
+	output := &ec2.DescribeSecurityGroupsOutput{}
+	err := c.DescribeSecurityGroupsPagesWithContext(c.ctx, input, func(page *ec2.DescribeSecurityGroupsOutput, lastPage bool) bool {
+		output.SecurityGroups = append(output.SecurityGroups, page.SecurityGroups...)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return output, nil
 }
 
 func (c *SGClient) FetchEc2Instance(iid *string) (*ec2.DescribeInstancesOutput, error) {
 	input := &ec2.DescribeInstancesInput{
 		InstanceIds: []*string{iid},
 	}
-	return c.DescribeInstances(input)
+	return c.DescribeInstancesWithContext(c.ctx, input)
 }
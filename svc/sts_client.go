@@ -0,0 +1,38 @@
+package svc
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sts"
+)
+
+type STSClient struct {
+	*sts.STS
+	ctx aws.Context
+}
+
+func (c *STSClient) FetchCallerIdentity() (*sts.GetCallerIdentityOutput, error) {
+	input := &sts.GetCallerIdentityInput{}
+	return c.GetCallerIdentityWithContext(c.ctx, input)
+}
+
+//Identity is the caller the api calls are made as
+type Identity struct {
+	Account string
+	Arn     string
+	UserID  string
+	Region  string
+}
+
+//WhoAmI returns the caller identity and the region the clients call
+func (m *Manager) WhoAmI() (*Identity, error) {
+	output, err := m.FetchCallerIdentity()
+	if err != nil {
+		return nil, err
+	}
+	return &Identity{
+		Account: aws.StringValue(output.Account),
+		Arn:     aws.StringValue(output.Arn),
+		UserID:  aws.StringValue(output.UserId),
+		Region:  m.Region,
+	}, nil
+}
@@ -0,0 +1,25 @@
+package util
+
+import (
+	"log"
+	"os"
+)
+
+//Verbose enables Debugf and LogError
+var Verbose bool
+
+var logger = log.New(os.Stderr, "", log.LstdFlags|log.Lmicroseconds)
+
+//Debugf logs a DEBUG line in verbose mode
+func Debugf(format string, v ...interface{}) {
+	if Verbose {
+		logger.Printf("DEBUG "+format, v...)
+	}
+}
+
+//LogError logs an ERROR line in verbose mode
+func LogError(err error) {
+	if Verbose {
+		logger.Printf("ERROR %s", err)
+	}
+}
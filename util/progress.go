@@ -0,0 +1,60 @@
+package util
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+//Progress prints a counter like "route tables (ap-northeast-1): 12/40 VPCs" to w.
+//It is updated in place when w is a terminal, otherwise only the final count is printed.
+//Nothing is printed with Quiet.
+type Progress struct {
+	w       io.Writer
+	label   string
+	total   int
+	done    int
+	inPlace bool
+	mu      sync.Mutex
+}
+
+func NewProgress(w io.Writer, label string, total int) *Progress {
+	f, ok := w.(*os.File)
+	//the debug lines of Verbose would break the line updated in place
+	return &Progress{w: w, label: label, total: total, inPlace: ok && isTerminal(f) && !Verbose}
+}
+
+//NewLineProgress is NewProgress printing only the final count even on a terminal,
+//for progresses running concurrently which would overwrite each other's line
+func NewLineProgress(w io.Writer, label string, total int) *Progress {
+	return &Progress{w: w, label: label, total: total}
+}
+
+//Increment counts one done and reprints the counter
+func (p *Progress) Increment() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.done++
+	if p.inPlace && !Quiet {
+		fmt.Fprintf(p.w, "\r%s", p.line())
+	}
+}
+
+//Finish ends the line of the counter
+func (p *Progress) Finish() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if Quiet || p.total == 0 {
+		return
+	}
+	if p.inPlace {
+		fmt.Fprintln(p.w)
+		return
+	}
+	fmt.Fprintln(p.w, p.line())
+}
+
+func (p *Progress) line() string {
+	return fmt.Sprintf("%s: %d/%d VPCs", p.label, p.done, p.total)
+}
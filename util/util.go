@@ -2,67 +2,267 @@ package util
 
 import (
 	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strings"
 
-	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/awsutil"
+	"github.com/aws/aws-sdk-go/aws/credentials/ssocreds"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sso"
+	"github.com/aws/aws-sdk-go/service/sts"
 	"github.com/urfave/cli"
 )
 
 const (
 	accessKeyID     = "AWS_ACCESS_KEY_ID"
 	secretAccessKey = "AWS_SECRET_ACCESS_KEY"
+	sessionToken    = "AWS_SESSION_TOKEN"
 	defaultRegion   = "AWS_DEFAULT_REGION"
+	awsProfile      = "AWS_PROFILE"
 )
 
+//startupEnv are the credentials and region in the environment before ConfigAWS exports its own
+var startupEnv = lookupEnvs(accessKeyID, secretAccessKey, sessionToken, defaultRegion)
+
+type envValue struct {
+	value string
+	ok    bool
+}
+
+func lookupEnvs(keys ...string) map[string]envValue {
+	envs := make(map[string]envValue)
+	for _, k := range keys {
+		v, ok := os.LookupEnv(k)
+		envs[k] = envValue{value: v, ok: ok}
+	}
+	return envs
+}
+
+func restoreStartupEnv() {
+	for k, v := range startupEnv {
+		if v.ok {
+			os.Setenv(k, v.value)
+		} else {
+			os.Unsetenv(k)
+		}
+	}
+}
+
+//Stdout is where Println* print. It is switched to stderr while a report is written to stdout.
+var Stdout io.Writer = os.Stdout
+
+//Quiet silences PrintlnGreen and PrintlnYellow. Errors are still printed.
+var Quiet bool
+
+//ConfigAWS sets credentials of the profile and region to environment variables.
+//The region of the profile is used unless --awsregion is given explicitly.
+//When --assume-role-arn is given, credentials of the assumed role are set instead.
+//AWS_PROFILE is used as the profile unless --profile is given. --profile-from-env requires it to be set.
+//Profiles of IAM Identity Center (sso_start_url or sso_session) use the token cached by aws sso login.
 func ConfigAWS(c *cli.Context) error {
+	//called again every --watch cycle, the role must be assumed with the original credentials,
+	//not with those of the previous cycle exported to the environment
+	restoreStartupEnv()
 	region := c.GlobalString("awsregion")
-	os.Setenv(defaultRegion, region)
 	name := c.GlobalString("awsconf")
+	roleArn := c.GlobalString("assume-role-arn")
 	if name == "" {
+		name = os.Getenv(awsProfile)
+		if name == "" && c.GlobalBool("profile-from-env") {
+			return fmt.Errorf("--profile-from-env is given but %s is not set", awsProfile)
+		}
+	}
+	if name == "" && roleArn == "" {
+		os.Setenv(defaultRegion, region)
 		return nil
 	}
-	cred := credentials.NewSharedCredentials("", name)
-	credValue, err := cred.Get()
+	//the sdk falls back to the default credential chain for a profile which does not exist
+	if name != "" && !profileExists(name) {
+		return fmt.Errorf("profile %s does not exist in ~/.aws/credentials or ~/.aws/config", name)
+	}
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Profile:           name,
+		SharedConfigState: session.SharedConfigEnable,
+	})
 	if err != nil {
 		return err
 	}
-	PrintlnGreen(fmt.Sprintf("AWS Profile Name: %s, Region: %s", name, region))
+	if !c.GlobalIsSet("awsregion") && sess.Config.Region != nil && *sess.Config.Region != "" {
+		region = *sess.Config.Region
+	}
+	sess = sess.Copy(&aws.Config{Region: aws.String(region)})
+	creds := sess.Config.Credentials
+	if roleArn != "" {
+		if c.GlobalBool("dry-run") {
+			//the role is neither assumed nor verified so that no api is called
+			printDryRunCall("sts:AssumeRole", region, &sts.AssumeRoleInput{
+				RoleArn:         aws.String(roleArn),
+				RoleSessionName: aws.String(c.GlobalString("role-session-name")),
+				ExternalId:      optionalString(c.GlobalString("external-id")),
+			})
+			printDryRunCall("sts:GetCallerIdentity", region, &sts.GetCallerIdentityInput{})
+			os.Setenv(defaultRegion, region)
+			return nil
+		}
+		creds = stscreds.NewCredentials(sess, roleArn, func(p *stscreds.AssumeRoleProvider) {
+			p.RoleSessionName = c.GlobalString("role-session-name")
+			p.ExternalID = optionalString(c.GlobalString("external-id"))
+		})
+	}
+	credValue, err := creds.Get()
+	if err != nil {
+		if isSsoTokenError(err) {
+			return fmt.Errorf("the sso token of profile %s is expired or not found. run `aws sso login --profile %s` and retry", name, name)
+		}
+		if roleArn != "" {
+			return fmt.Errorf("failed to assume role %s: %s", roleArn, err)
+		}
+		return fmt.Errorf("failed to load credentials of profile %s: %s", name, err)
+	}
+	if name != "" {
+		PrintlnGreen(fmt.Sprintf("AWS Profile Name: %s, Region: %s", name, region))
+	}
+	if roleArn != "" {
+		identity, err := sts.New(sess, &aws.Config{Credentials: creds}).GetCallerIdentity(&sts.GetCallerIdentityInput{})
+		if err != nil {
+			return fmt.Errorf("failed to verify assumed role %s: %s", roleArn, err)
+		}
+		PrintlnGreen(fmt.Sprintf("Assumed Role: %s, Account: %s, Region: %s", *identity.Arn, *identity.Account, region))
+	}
+	os.Setenv(defaultRegion, region)
 	os.Setenv(accessKeyID, credValue.AccessKeyID)
 	os.Setenv(secretAccessKey, credValue.SecretAccessKey)
+	os.Setenv(sessionToken, credValue.SessionToken)
 	return nil
 }
 
+//printDryRunCall prints the api call in the format of the dry-run handler of the managers
+func printDryRunCall(operation, region string, params interface{}) {
+	fmt.Printf("[dry-run] %s (%s) %s\n", operation, region, strings.Join(strings.Fields(awsutil.Prettify(params)), " "))
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return aws.String(s)
+}
+
+//profileExists reports whether the profile is defined in the shared credentials or config file.
+//AWS_SHARED_CREDENTIALS_FILE and AWS_CONFIG_FILE override the files as in the sdk.
+func profileExists(name string) bool {
+	home, _ := os.UserHomeDir()
+	files := []struct {
+		env      string
+		path     string
+		sections []string
+	}{
+		{"AWS_SHARED_CREDENTIALS_FILE", filepath.Join(home, ".aws", "credentials"), []string{name}},
+		{"AWS_CONFIG_FILE", filepath.Join(home, ".aws", "config"), []string{name, "profile " + name}},
+	}
+	for _, f := range files {
+		path := f.path
+		if v := os.Getenv(f.env); v != "" {
+			path = v
+		}
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		for _, line := range strings.Split(string(b), "\n") {
+			line = strings.TrimSpace(line)
+			if !strings.HasPrefix(line, "[") || !strings.HasSuffix(line, "]") {
+				continue
+			}
+			section := strings.Join(strings.Fields(line[1:len(line)-1]), " ")
+			for _, s := range f.sections {
+				if section == s {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+//isSsoTokenError reports whether err or an error it wraps is caused by a missing, expired or revoked sso token
+func isSsoTokenError(err error) bool {
+	for err != nil {
+		aerr, ok := err.(awserr.Error)
+		if !ok {
+			return false
+		}
+		switch aerr.Code() {
+		case ssocreds.ErrCodeSSOProviderInvalidToken, sso.ErrCodeUnauthorizedException:
+			return true
+		}
+		err = aerr.OrigErr()
+	}
+	return false
+}
+
+//NoColor makes the functions below print without ANSI color codes.
+//It is enabled by default when stdout or stderr is not a terminal, or NO_COLOR is set.
+var NoColor = os.Getenv("NO_COLOR") != "" || !isTerminal(os.Stdout) || !isTerminal(os.Stderr)
+
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
+
+func colorize(code, s string) string {
+	if NoColor {
+		return s
+	}
+	return fmt.Sprintf("\x1b[%sm%s\x1b[0m", code, s)
+}
+
 //PrintlnGreen Println in Green
 func PrintlnGreen(s string) {
-	fmt.Printf("\x1b[32m%s\x1b[0m\n", s)
+	if Quiet {
+		return
+	}
+	fmt.Fprintln(Stdout, colorize("32", s))
 }
 
 //PrintlnRed Println in Red
 func PrintlnRed(s string) {
-	fmt.Printf("\x1b[31m%s\x1b[0m\n", s)
+	fmt.Fprintln(Stdout, colorize("31", s))
 }
 
 //PrintlnYellow Println in Yellow
 func PrintlnYellow(s string) {
-	fmt.Printf("\x1b[33m%s\x1b[0m\n", s)
+	if Quiet {
+		return
+	}
+	fmt.Fprintln(Stdout, colorize("33", s))
 }
 
 //ErrorlnRed Error in Red
 func ErrorRed(s string) error {
-	return fmt.Errorf("\x1b[31m%s\x1b[0m", s)
+	return fmt.Errorf("%s", colorize("31", s))
 }
 
 //SprintGreen Sprintf in Green
 func SprintGreen(s string) string {
-	return fmt.Sprintf("\x1b[32m%s\x1b[0m", s)
+	return colorize("32", s)
 }
 
 //SprintRed Sprintf in Red
 func SprintRed(s string) string {
-	return fmt.Sprintf("\x1b[31m%s\x1b[0m", s)
+	return colorize("31", s)
 }
 
 //SprintYellow Sprintf in Yellow
 func SprintYellow(s string) string {
-	return fmt.Sprintf("\x1b[33m%s\x1b[0m", s)
+	return colorize("33", s)
 }